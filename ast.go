@@ -3,7 +3,10 @@ package godetector
 import (
 	"fmt"
 	"go/ast"
+	"go/token"
+	"path/filepath"
 	"strconv"
+	"strings"
 )
 
 type LocationType int
@@ -15,6 +18,7 @@ const (
 	GoPath        LocationType = 3
 	GoRoot        LocationType = 4
 	GoCache       LocationType = 5
+	GoWork        LocationType = 6 // module from go.work workspace other than module of working directory
 )
 
 type Import struct {
@@ -23,59 +27,201 @@ type Import struct {
 	Location            string // /opt/go/src/example.com/project/alfa/beta/gamma
 	RootPackageLocation string // /opt/go/src/example.com/project/alfa
 	Type                LocationType
+	Version             string   // module version if known
+	Replaced            bool     // location defined by replace directive in go.mod
+	Chain               []string // requirement chain which selected module version: main module, intermediate modules, selected module@version
+	Module              *Module  // module which owns package. Nil for GOPATH packages
+	IsMain              bool     // package is a command (package main)
+	HasTests            bool     // directory contains _test.go files. Detected only by ListPackages
 }
 
 func (ipi *importPathInfo) ToImport() *Import {
+	return ipi.origin().toImport(ipi)
+}
+
+func (ipi *importPathInfo) Root() (*Import, error) {
+	return ipi.origin().InspectImportByDir(ipi.PackageRootDir)
+}
+
+func (r *Resolver) toImport(ipi *importPathInfo) *Import {
 	if ipi == nil {
 		return nil
 	}
+	name := r.packageName(ipi.ImportDir, ipi.Import)
 	return &Import{
 		Path:                ipi.Import,
-		Package:             FindPackageNameByDir(ipi.ImportDir),
+		Package:             name,
 		Location:            ipi.ImportDir,
 		RootPackageLocation: ipi.PackageRootDir,
 		Type:                ipi.LocationType,
+		Version:             ipi.Version,
+		Replaced:            ipi.Replaced,
+		Chain:               append([]string(nil), ipi.Chain...),
+		Module:              ipi.Module.copy(),
+		IsMain:              name == "main",
 	}
 
 }
 
-func (ipi *importPathInfo) Root() (*Import, error) {
-	return InspectImportByDir(ipi.PackageRootDir)
-}
-
 // Find correct import definition in a file: "lala" "net/http" will be resolve to "net/http"
 func ResolveImport(alias string, file *ast.File, workdir string) (*Import, error) {
+	return DefaultResolver().ResolveImport(alias, file, workdir)
+}
+
+// Find correct import definition in a file using resolver. Imports of file are inspected lazily and inspection results
+// are cached by resolver: imports which assumed name (see ImportPathToAssumedName) matches alias are checked first,
+// unrelated imports which can't be resolved are skipped.
+func (r *Resolver) ResolveImport(alias string, file *ast.File, workdir string) (*Import, error) {
 	if alias == "" {
 		return nil, fmt.Errorf("aliase or import path should be defined")
 	}
+	table := newImportTable(file, workdir)
 	// priority to aliases
-	for _, imp := range file.Imports {
-		if imp.Name != nil {
-			if imp.Name.Name == alias {
-				importPath, _ := strconv.Unquote(imp.Path.Value)
-				info, err := InspectImport(importPath, workdir)
-				return info.ToImport(), err
-			}
+	for _, imp := range table.Imports {
+		if imp.Name == alias {
+			return table.resolve(r, imp)
 		}
 	}
-	for _, imp := range file.Imports {
+	// aliased, dot and blank imports can't be referenced by package name
+	var likely, others []*fileImport
+	for _, imp := range table.Imports {
+		if imp.Name != "" {
+			continue
+		}
+		if ImportPathToAssumedName(imp.Path) == alias {
+			likely = append(likely, imp)
+		} else {
+			others = append(others, imp)
+		}
+	}
+	var failed []string
+	for _, imp := range append(likely, others...) {
+		pkg, err := table.resolve(r, imp)
+		if err != nil {
+			failed = append(failed, imp.Path+": "+err.Error())
+			continue
+		}
+		if pkg.Package == alias {
+			return pkg, nil
+		}
+	}
+	if len(failed) > 0 {
+		return nil, fmt.Errorf("unresolved alias or package %s (failed imports: %s)", alias, strings.Join(failed, "; "))
+	}
+	return nil, fmt.Errorf("unresolved alias or package %s", alias)
+}
 
-		path, _ := strconv.Unquote(imp.Path.Value)
+// Create import table of file imported from work directory
+func newImportTable(file *ast.File, workdir string) *importTable {
+	if abs, err := filepath.Abs(workdir); err == nil {
+		workdir = abs
+	}
+	table := &importTable{WorkDir: workdir}
+	for _, spec := range file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		imp := &fileImport{Path: path}
+		if spec.Name != nil {
+			imp.Name = spec.Name.Name
+		}
+		table.Imports = append(table.Imports, imp)
+	}
+	return table
+}
+
+// Imports of single file
+type importTable struct {
+	WorkDir string
+	Imports []*fileImport
+}
+
+// Import declaration of file
+type fileImport struct {
+	Name string // explicit name (alias, dot or blank). Empty if not defined
+	Path string
+}
+
+// Resolve import of file. Results are cached by resolver cache
+func (table *importTable) resolve(r *Resolver, imp *fileImport) (*Import, error) {
+	info, err := r.InspectImport(imp.Path, table.WorkDir)
+	if err != nil {
+		return nil, err
+	}
+	return r.toImport(info), nil
+}
+
+// Find dot-imported package (import . "example.com/pkg") of a file which declares unqualified identifier
+func ResolveDotImport(name string, file *ast.File, workdir string) (*Import, error) {
+	return DefaultResolver().ResolveDotImport(name, file, workdir)
+}
 
-		pkgInfo, err := InspectImport(path, workdir)
+// Find dot-imported package which declares unqualified identifier using resolver
+func (r *Resolver) ResolveDotImport(name string, file *ast.File, workdir string) (*Import, error) {
+	if !ast.IsExported(name) {
+		// only exported identifiers are visible through dot import
+		return nil, fmt.Errorf("%s is not exported and can't be dot-imported", name)
+	}
+	var lastErr error
+	table := newImportTable(file, workdir)
+	for _, imp := range table.Imports {
+		if imp.Name != "." {
+			continue
+		}
+		pkg, err := table.resolve(r, imp)
 		if err != nil {
-			return nil, err
+			lastErr = err
+			continue
 		}
-		imp := pkgInfo.ToImport()
-		if imp.Package == alias {
-			return imp, nil
+		if r.declares(pkg.Location, name) {
+			return pkg, nil
 		}
 	}
-	return nil, fmt.Errorf("unresolved alias or package %s", alias)
+	if lastErr != nil {
+		return nil, fmt.Errorf("%s is not declared in dot-imported packages: %w", name, lastErr)
+	}
+	return nil, fmt.Errorf("%s is not declared in dot-imported packages", name)
+}
+
+// Check that package in directory declares top-level identifier: type, constant, variable or function
+func (r *Resolver) declares(dir string, name string) bool {
+	var fs token.FileSet
+	pkgs, _ := r.ParseDir(&fs, dir, r.buildFilter(dir), 0)
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				switch v := decl.(type) {
+				case *ast.FuncDecl:
+					if v.Recv == nil && v.Name.Name == name {
+						return true
+					}
+				case *ast.GenDecl:
+					for _, spec := range v.Specs {
+						switch sp := spec.(type) {
+						case *ast.TypeSpec:
+							if sp.Name.Name == name {
+								return true
+							}
+						case *ast.ValueSpec:
+							for _, ident := range sp.Names {
+								if ident.Name == name {
+									return true
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return false
 }
 
 // Aggregated information about directory
 func InspectImportByDir(pkgDir string) (*Import, error) {
-	info, err := InspectDirectory(pkgDir)
-	return info.ToImport(), err
+	return DefaultResolver().InspectImportByDir(pkgDir)
+}
+
+// Aggregated information about directory using resolver
+func (r *Resolver) InspectImportByDir(pkgDir string) (*Import, error) {
+	info, err := r.InspectDirectory(pkgDir)
+	return r.toImport(info), err
 }
@@ -0,0 +1,156 @@
+package godetector
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go/parser"
+	"go/token"
+	"os"
+	"testing"
+	"testing/fstest"
+)
+
+func TestResolver_ResolveImport_assumedName(t *testing.T) {
+	r := &Resolver{
+		GOROOT:   "/goroot",
+		ModCache: "/modcache",
+		Work:     "off",
+		FS: NewFS(fstest.MapFS{
+			"goroot/src/fmt/print.go":                          {Data: []byte("package fmt\n")},
+			"modcache/example.com/go-bar/v2@v2.0.0/go.mod":     {Data: []byte("module example.com/go-bar/v2\n")},
+			"modcache/example.com/go-bar/v2@v2.0.0/bar_gen.go": {Data: []byte("//go:build ignore\n\npackage main\n")},
+			"modcache/gopkg.in/yaml.v3@v3.0.1/go.mod":          {Data: []byte("module gopkg.in/yaml.v3\n")},
+			"project/go.mod":                                   {Data: []byte("module example.com/project\n\nrequire (\n\texample.com/go-bar/v2 v2.0.0\n\tgopkg.in/yaml.v3 v3.0.1\n)\n")},
+		}),
+	}
+	var fs token.FileSet
+	file, err := parser.ParseFile(&fs, "main.go", `package main
+
+import (
+	"fmt"
+	"example.com/go-bar/v2"
+	"gopkg.in/yaml.v3"
+)
+`, parser.ImportsOnly)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	imp, err := r.ResolveImport("bar", file, "/project")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "example.com/go-bar/v2", imp.Path)
+		assert.Equal(t, "bar", imp.Package)
+	}
+
+	imp, err = r.ResolveImport("yaml", file, "/project")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "gopkg.in/yaml.v3", imp.Path)
+		assert.Equal(t, "yaml", imp.Package)
+	}
+}
+
+func TestResolver_ResolveDotImport(t *testing.T) {
+	r := &Resolver{
+		Work: "off",
+		FS: NewFS(fstest.MapFS{
+			"project/go.mod":         {Data: []byte("module example.com/project\n")},
+			"project/alfa/alfa.go":   {Data: []byte("package alfa\n\ntype Alfa int\n\nfunc (Alfa) Method() {}\n")},
+			"project/beta/beta.go":   {Data: []byte("package beta\n\ntype Beta int\n\nconst Gamma Beta = 1\n")},
+			"project/broken/README":  {Data: []byte("not a package\n")},
+			"project/model/model.go": {Data: []byte("package model\n")},
+		}),
+	}
+	var fs token.FileSet
+	file, err := parser.ParseFile(&fs, "model.go", `package model
+
+import (
+	_ "example.com/project/missing"
+	. "example.com/project/alfa"
+	. "example.com/project/beta"
+)
+`, parser.ImportsOnly)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	imp, err := r.ResolveDotImport("Beta", file, "/project/model")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "example.com/project/beta", imp.Path)
+	}
+	imp, err = r.ResolveDotImport("Alfa", file, "/project/model")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "example.com/project/alfa", imp.Path)
+	}
+	_, err = r.ResolveDotImport("Method", file, "/project/model")
+	assert.Error(t, err)
+	_, err = r.ResolveDotImport("string", file, "/project/model")
+	assert.Error(t, err)
+
+	// blank and dot imports are not referenced by package name
+	_, err = r.ResolveImport("missing", file, "/project/model")
+	assert.Error(t, err)
+	_, err = r.ResolveImport("alfa", file, "/project/model")
+	assert.Error(t, err)
+}
+
+func TestResolver_ResolveImport_lazy(t *testing.T) {
+	fsys := &countingFS{FileSystem: NewFS(fstest.MapFS{
+		"project/go.mod":       {Data: []byte("module example.com/project\n")},
+		"project/alfa/alfa.go": {Data: []byte("package alfa\n")},
+		"project/beta/beta.go": {Data: []byte("package beta\n")},
+		"project/delta/eps.go": {Data: []byte("package epsilon\n")},
+	})}
+	r := &Resolver{Work: "off", FS: fsys}
+	var fs token.FileSet
+	file, err := parser.ParseFile(&fs, "main.go", `package main
+
+import (
+	"example.com/broken/pkg"
+	"example.com/project/beta"
+	"example.com/project/alfa"
+	"example.com/project/delta"
+)
+`, parser.ImportsOnly)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// broken import should not block resolution
+	imp, err := r.ResolveImport("epsilon", file, "/project")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "example.com/project/delta", imp.Path)
+	}
+
+	imp, err = r.ResolveImport("alfa", file, "/project")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "example.com/project/alfa", imp.Path)
+	}
+	reads := fsys.calls
+	imp, err = r.ResolveImport("alfa", file, "/project")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "example.com/project/alfa", imp.Path)
+	}
+	assert.Equal(t, reads, fsys.calls, "second resolution should be cached")
+
+	_, err = r.ResolveImport("pkg", file, "/project")
+	assert.Error(t, err)
+}
+
+type countingFS struct {
+	FileSystem
+	calls int
+}
+
+func (c *countingFS) Stat(name string) (os.FileInfo, error) {
+	c.calls++
+	return c.FileSystem.Stat(name)
+}
+
+func (c *countingFS) ReadDir(name string) ([]os.FileInfo, error) {
+	c.calls++
+	return c.FileSystem.ReadDir(name)
+}
+
+func (c *countingFS) ReadFile(name string) ([]byte, error) {
+	c.calls++
+	return c.FileSystem.ReadFile(name)
+}
@@ -0,0 +1,259 @@
+package godetector
+
+import (
+	"golang.org/x/mod/modfile"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache of resolution results: directory to module mapping, inspected imports, parsed go.mod files and package names.
+// Cache could be shared between resolvers with the same environment and it is safe for concurrent use.
+//
+// Cached entries remember files they depend on. Use Invalidate to drop entries after changes of go.mod or sources,
+// or set CheckModTime to revalidate entries automatically by modification time and size of those files.
+type Cache struct {
+	CheckModTime bool // revalidate entries by modification time of files they depend on on every access
+
+	lock    sync.RWMutex
+	entries map[cacheKey]*cacheEntry
+}
+
+// Create new empty cache
+func NewCache() *Cache {
+	return &Cache{}
+}
+
+type cacheKind int
+
+const (
+	cacheDir        cacheKind = 0 // directory inspection
+	cacheImport     cacheKind = 1 // import inspection from work directory
+	cacheModFile    cacheKind = 2 // parsed go.mod file
+	cacheName       cacheKind = 3 // package name of directory
+	cacheDepModFile cacheKind = 4 // leniently parsed go.mod file of dependency
+	cacheBuildList  cacheKind = 5 // selected versions of modules for main module or workspace
+	cacheStdList    cacheKind = 6 // packages of standard library
+	cacheModHash    cacheKind = 7 // checksums of module directory and go.mod
+)
+
+type cacheKey struct {
+	kind       cacheKind
+	path       string // directory, work directory or file
+	importPath string // only for imports
+}
+
+type cacheEntry struct {
+	value interface{}
+	err   error
+	deps  []cacheStamp
+}
+
+// State of file or directory at the moment of caching
+type cacheStamp struct {
+	path    string
+	exists  bool
+	modTime time.Time
+	size    int64
+}
+
+// Drop all cached entries which depend on path, or on files and directories under path.
+func (c *Cache) Invalidate(path string) {
+	path = filepath.Clean(path)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for key, entry := range c.entries {
+		if isUnder(key.path, path) {
+			delete(c.entries, key)
+			continue
+		}
+		for _, dep := range entry.deps {
+			if isUnder(dep.path, path) {
+				delete(c.entries, key)
+				break
+			}
+		}
+	}
+}
+
+// Drop all cached entries
+func (c *Cache) Reset() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.entries = nil
+}
+
+// Drop single entry
+func (c *Cache) forget(key cacheKey) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.entries, key)
+}
+
+// Get cached value or calculate it. Calculation returns value and list of files (directories) the value depends on
+func (c *Cache) get(fsys FileSystem, key cacheKey, calc func() (interface{}, []string, error)) (interface{}, error) {
+	c.lock.RLock()
+	entry, ok := c.entries[key]
+	c.lock.RUnlock()
+	if ok && (!c.CheckModTime || entry.valid(fsys)) {
+		return entry.value, entry.err
+	}
+	value, deps, err := calc()
+	entry = &cacheEntry{value: value, err: err}
+	for _, dep := range deps {
+		entry.deps = append(entry.deps, stamp(fsys, dep))
+	}
+	c.lock.Lock()
+	if c.entries == nil {
+		c.entries = make(map[cacheKey]*cacheEntry)
+	}
+	c.entries[key] = entry
+	c.lock.Unlock()
+	return value, err
+}
+
+func (entry *cacheEntry) valid(fsys FileSystem) bool {
+	for _, dep := range entry.deps {
+		if stamp(fsys, dep.path) != dep {
+			return false
+		}
+	}
+	return true
+}
+
+func stamp(fsys FileSystem, path string) cacheStamp {
+	info, err := fsys.Stat(path)
+	if err != nil {
+		return cacheStamp{path: path}
+	}
+	return cacheStamp{
+		path:    path,
+		exists:  true,
+		modTime: info.ModTime(),
+		size:    info.Size(),
+	}
+}
+
+// Check that path is the same as root or located under root
+func isUnder(path, root string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func (r *Resolver) cache() *Cache {
+	if r.Cache != nil {
+		return r.Cache
+	}
+	return &r.ownCache
+}
+
+// Inspect absolute directory with cache. Result depends on directory itself and go.mod files in directory and every
+// upper directory up to module root (any of them could become a nested module). Failed inspection depends on go.mod
+// files of all upper directories
+func (r *Resolver) inspectDirectory(dir string) (*importPathInfo, error) {
+	value, err := r.cache().get(r.fs(), cacheKey{kind: cacheDir, path: dir}, func() (interface{}, []string, error) {
+		info, err := r.scanDirectory(dir)
+		if err != nil {
+			return nil, append([]string{dir}, modFilesUpTo(dir, "")...), err
+		}
+		if vendorDir := vendorDirOf(info); info.LocationType == InLocalVendor && vendorDir != "" {
+			// vendored package depends on modules.txt and go.mod files up to main module
+			deps := append([]string{dir, filepath.Join(vendorDir, "modules.txt")}, modFilesUpTo(dir, filepath.Dir(vendorDir))...)
+			return *info, deps, nil
+		}
+		return *info, append([]string{dir}, modFilesUpTo(dir, info.PackageRootDir)...), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	info := value.(importPathInfo)
+	info.Module = info.Module.copy()
+	info.resolver = r
+	return &info, nil
+}
+
+// Locations of go.mod files in directory and upper directories up to root (inclusive). Empty root means top directory
+func modFilesUpTo(dir, root string) []string {
+	var files []string
+	for {
+		files = append(files, filepath.Join(dir, "go.mod"))
+		parent := filepath.Dir(dir)
+		if dir == root || parent == dir {
+			return files
+		}
+		dir = parent
+	}
+}
+
+// Inspect import from absolute work directory with cache. Result depends on work directory, found package directory
+// and files which define module of work directory: go.mod, go.work and vendor/modules.txt. Failed lookups are not
+// cached: package could appear in any of tried locations (ex: after go mod download)
+func (r *Resolver) inspectImportCached(importPath string, workDir string) (*importPathInfo, error) {
+	key := cacheKey{kind: cacheImport, path: workDir, importPath: importPath}
+	value, err := r.cache().get(r.fs(), key, func() (interface{}, []string, error) {
+		var deps = []string{workDir}
+		if root, err := r.inspectDirectory(workDir); err == nil {
+			deps = append(deps,
+				filepath.Join(root.PackageRootDir, "go.mod"),
+				filepath.Join(root.PackageRootDir, "vendor", "modules.txt"))
+		}
+		if r.Work != "off" {
+			if file := r.findWorkFile(workDir); file != "" {
+				deps = append(deps, file)
+			}
+		}
+		info, err := r.inspectImport(importPath, workDir, nil)
+		if err != nil {
+			return nil, deps, err
+		}
+		return *info, append(deps, info.ImportDir), nil
+	})
+	if err != nil {
+		r.cache().forget(key)
+		return nil, err
+	}
+	info := value.(importPathInfo)
+	info.Module = info.Module.copy()
+	info.resolver = r
+	return &info, nil
+}
+
+// Read and parse go.mod file with cache. Returned file should not be modified
+func (r *Resolver) readModFile(path string) (*modfile.File, error) {
+	value, err := r.cache().get(r.fs(), cacheKey{kind: cacheModFile, path: path}, func() (interface{}, []string, error) {
+		data, err := r.fs().ReadFile(path)
+		if err != nil {
+			return nil, []string{path}, err
+		}
+		mod, err := modfile.Parse(path, data, nil)
+		if err != nil {
+			return nil, []string{path}, badGoMod(path, err)
+		}
+		return mod, []string{path}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*modfile.File), nil
+}
+
+// Detect package name with cache. Result depends on directory and go files in it
+func (r *Resolver) detectPackageNameCached(dir string) (string, error) {
+	value, err := r.cache().get(r.fs(), cacheKey{kind: cacheName, path: dir}, func() (interface{}, []string, error) {
+		var deps = []string{dir}
+		if files, err := r.fs().ReadDir(dir); err == nil {
+			for _, file := range files {
+				if !file.IsDir() && filepath.Ext(file.Name()) == ".go" {
+					deps = append(deps, filepath.Join(dir, file.Name()))
+				}
+			}
+		}
+		name, err := r.detectPackageName(dir)
+		return name, deps, err
+	})
+	if err != nil {
+		return "", err
+	}
+	return value.(string), nil
+}
@@ -0,0 +1,109 @@
+package godetector
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestCache_Invalidate(t *testing.T) {
+	fsys := fstest.MapFS{
+		"project/go.mod":      {Data: []byte("module example.com/alfa\n")},
+		"project/pkg/pkg.go":  {Data: []byte("package pkg\n")},
+		"other/go.mod":        {Data: []byte("module example.com/other\n")},
+		"other/other/main.go": {Data: []byte("package main\n")},
+	}
+	cache := NewCache()
+	r := &Resolver{Work: "off", FS: NewFS(fsys), Cache: cache}
+
+	path, err := r.FindImportPath("/project/pkg")
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com/alfa/pkg", path)
+
+	fsys["project/go.mod"] = &fstest.MapFile{Data: []byte("module example.com/beta\n")}
+	path, _ = r.FindImportPath("/project/pkg")
+	assert.Equal(t, "example.com/alfa/pkg", path, "should be cached")
+
+	// other resolver with the same cache shares results
+	other := &Resolver{Work: "off", FS: NewFS(fsys), Cache: cache}
+	path, _ = other.FindImportPath("/project/pkg")
+	assert.Equal(t, "example.com/alfa/pkg", path, "should be cached")
+
+	cache.Invalidate("/project/go.mod")
+	path, err = r.FindImportPath("/project/pkg")
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com/beta/pkg", path)
+
+	info, err := r.InspectImport("example.com/beta/pkg", "/project")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "/project/pkg", info.ImportDir)
+	}
+
+	assert.Equal(t, "pkg", r.FindPackageNameByDir("/project/pkg"))
+	fsys["project/pkg/pkg.go"] = &fstest.MapFile{Data: []byte("package gamma\n")}
+	assert.Equal(t, "pkg", r.FindPackageNameByDir("/project/pkg"), "should be cached")
+	cache.Invalidate("/project")
+	assert.Equal(t, "gamma", r.FindPackageNameByDir("/project/pkg"))
+
+	cache.Reset()
+	path, _ = r.FindImportPath("/other/other")
+	assert.Equal(t, "example.com/other/other", path)
+}
+
+func TestCache_CheckModTime(t *testing.T) {
+	now := time.Now()
+	fsys := fstest.MapFS{
+		"project/go.mod":     {Data: []byte("module example.com/alfa\n"), ModTime: now},
+		"project/pkg/pkg.go": {Data: []byte("package pkg\n"), ModTime: now},
+	}
+	r := &Resolver{Work: "off", FS: NewFS(fsys), Cache: &Cache{CheckModTime: true}}
+
+	assert.Equal(t, "pkg", r.FindPackageNameByDir("/project/pkg"))
+	path, _ := r.FindImportPath("/project/pkg")
+	assert.Equal(t, "example.com/alfa/pkg", path)
+
+	fsys["project/pkg/pkg.go"] = &fstest.MapFile{Data: []byte("package gamma\n"), ModTime: now.Add(time.Second)}
+	fsys["project/go.mod"] = &fstest.MapFile{Data: []byte("module example.com/beta\n"), ModTime: now.Add(time.Second)}
+
+	assert.Equal(t, "gamma", r.FindPackageNameByDir("/project/pkg"))
+	path, _ = r.FindImportPath("/project/pkg")
+	assert.Equal(t, "example.com/beta/pkg", path)
+}
+
+func TestCache_failedImport(t *testing.T) {
+	fsys := fstest.MapFS{
+		"project/go.mod":  {Data: []byte("module example.com/project\n\nrequire example.com/dep v1.0.0\n")},
+		"project/main.go": {Data: []byte("package main\n")},
+	}
+	r := &Resolver{Work: "off", ModCache: "/mod", FS: NewFS(fsys), Cache: NewCache()}
+
+	_, err := r.InspectImport("example.com/dep", "/project")
+	assert.Error(t, err)
+
+	fsys["mod/example.com/dep@v1.0.0/go.mod"] = &fstest.MapFile{Data: []byte("module example.com/dep\n")}
+	fsys["mod/example.com/dep@v1.0.0/dep.go"] = &fstest.MapFile{Data: []byte("package dep\n")}
+	info, err := r.InspectImport("example.com/dep", "/project")
+	if assert.NoError(t, err, "failed lookup should not be cached") {
+		assert.Equal(t, "/mod/example.com/dep@v1.0.0", info.ImportDir)
+	}
+}
+
+func TestCache_nestedModule(t *testing.T) {
+	fsys := fstest.MapFS{
+		"project/go.mod":       {Data: []byte("module example.com/project\n")},
+		"project/tools/x/x.go": {Data: []byte("package x\n")},
+	}
+	cache := NewCache()
+	r := &Resolver{Work: "off", FS: NewFS(fsys), Cache: cache}
+
+	path, err := r.FindImportPath("/project/tools/x")
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com/project/tools/x", path)
+
+	fsys["project/tools/go.mod"] = &fstest.MapFile{Data: []byte("module example.com/tools\n")}
+	cache.Invalidate("/project/tools/go.mod")
+	path, err = r.FindImportPath("/project/tools/x")
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com/tools/x", path)
+}
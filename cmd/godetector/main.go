@@ -9,6 +9,7 @@ import (
 
 func main() {
 	dir := flag.String("dir", ".", "Dirname to change")
+	explain := flag.Bool("explain", false, "Explain resolution of imports: print every examined location")
 	flag.Parse()
 	fmt.Println("Current directory info")
 
@@ -22,7 +23,15 @@ func main() {
 		fmt.Println("Imports definitions")
 	}
 	for _, arg := range flag.Args() {
-
+		if *explain {
+			trace, err := godetector.ExplainImport(arg, *dir)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "  failed explain import", arg, ":", err)
+				continue
+			}
+			fmt.Print(trace)
+			continue
+		}
 		if defDir, err := godetector.FindPackageDefinitionDir(arg, *dir); err == nil {
 			fmt.Println(" ", arg, "=>", defDir)
 		} else {
@@ -17,6 +17,7 @@ type Typer struct {
 	Ordered       []*Definition          // Inspected and parsed definition in order of inspection
 	Parsed        map[string]*Definition // Indexed definition where index is <path>@<type>
 	BeforeInspect func(def *Definition)  // Invoke hook before inspection (ex: RemoveJsonIgnoredFields)
+	Resolver      *godetector.Resolver   // Resolver for imports and filesystem access. Environment-based resolver used if not set
 }
 
 // Add recursively pre-parsed structure definition
@@ -38,7 +39,7 @@ func (tsg *Typer) Add(def *Definition) {
 	for _, f := range def.StructFields() {
 		alias := DetectPackageInType(f.AST.Type)
 		typeName := RebuildTypeNameWithoutPackage(f.AST.Type)
-		def := FindDefinitionFromAst(typeName, alias, def.File, def.FileDir)
+		def := findDefinition(tsg.resolver(), typeName, alias, def.File, def.FileDir)
 		f.Definition = def
 		if def != nil {
 			tsg.Add(def)
@@ -48,7 +49,7 @@ func (tsg *Typer) Add(def *Definition) {
 
 // Parse and add recursively type from directory. Do nothing if not found
 func (tsg *Typer) AddFromDir(typeName string, dir string) {
-	def := FindDefinitionFromAst(typeName, "", nil, dir)
+	def := findDefinition(tsg.resolver(), typeName, "", nil, dir)
 	if def == nil {
 		return
 	}
@@ -62,13 +63,20 @@ func (tsg *Typer) AddFromFile(typeName string, filename string) {
 
 // Parse and add type using full import name using current working directory
 func (tsg *Typer) AddFromImport(typeName string, importPath string) {
-	location, err := godetector.FindPackageDefinitionDir(importPath, ".")
+	location, err := tsg.resolver().FindPackageDefinitionDir(importPath, ".")
 	if err != nil {
 		return
 	}
 	tsg.AddFromDir(typeName, location)
 }
 
+func (tsg *Typer) resolver() *godetector.Resolver {
+	if tsg.Resolver == nil {
+		tsg.Resolver = godetector.DefaultResolver()
+	}
+	return tsg.Resolver
+}
+
 type Definition struct {
 	Import   godetector.Import
 	Decl     *ast.GenDecl
@@ -83,25 +91,41 @@ type Definition struct {
 }
 
 func FindDefinitionFromAst(typeName, alias string, file *ast.File, fileDir string) *Definition {
+	return findDefinition(godetector.DefaultResolver(), typeName, alias, file, fileDir)
+}
+
+func findDefinition(resolver *godetector.Resolver, typeName, alias string, file *ast.File, fileDir string) *Definition {
 	var importDef godetector.Import
 	if alias != "" {
-		v, err := godetector.ResolveImport(alias, file, fileDir)
+		v, err := resolver.ResolveImport(alias, file, fileDir)
 		if err != nil {
 			log.Println("failed resolve import for", alias, "from dir", fileDir, ":", err)
 			return nil
 		}
 		importDef = *v
 	} else {
-		v, err := godetector.InspectImportByDir(fileDir)
+		v, err := resolver.InspectImportByDir(fileDir)
 		if err != nil {
 			log.Println("failed inspect", fileDir, ":", err)
 			return nil
 		}
 		importDef = *v
+		if def := lookupDefinition(resolver, typeName, importDef); def != nil || file == nil {
+			return def
+		}
+		// unqualified type could be declared in dot-imported package
+		v, err = resolver.ResolveDotImport(typeName, file, fileDir)
+		if err != nil {
+			return nil
+		}
+		importDef = *v
 	}
+	return lookupDefinition(resolver, typeName, importDef)
+}
 
+func lookupDefinition(resolver *godetector.Resolver, typeName string, importDef godetector.Import) *Definition {
 	var fs token.FileSet
-	importFile, err := parser.ParseDir(&fs, importDef.Location, nil, parser.AllErrors)
+	importFile, err := resolver.ParseDir(&fs, importDef.Location, nil, parser.AllErrors)
 	if err != nil {
 		log.Println("failed parse", importDef.Location, ":", err)
 		return nil
@@ -1,6 +1,10 @@
 package deepparser
 
-import "testing"
+import (
+	"github.com/reddec/godetector"
+	"testing"
+	"testing/fstest"
+)
 
 func TestFindDefinitionFromAst_enum(t *testing.T) {
 	var typer Typer
@@ -26,3 +30,44 @@ func TestFindDefinitionFromAst_enum(t *testing.T) {
 		t.Log(val.Name, "=", val.Value)
 	}
 }
+
+func TestTyper_virtualFS(t *testing.T) {
+	typer := Typer{
+		Resolver: &godetector.Resolver{
+			Work: "off",
+			FS: godetector.NewFS(fstest.MapFS{
+				"project/go.mod":         {Data: []byte("module example.com/project\n")},
+				"project/model/model.go": {Data: []byte("package model\n\nimport \"example.com/project/types\"\n\ntype User struct {\n\tID types.ID\n}\n")},
+				"project/types/types.go": {Data: []byte("package types\n\ntype ID struct {\n\tValue string\n}\n")},
+			}),
+		},
+	}
+	typer.AddFromDir("User", "/project/model")
+	if len(typer.Ordered) != 2 {
+		t.Fatal("should be 2 definitions but got", len(typer.Ordered))
+	}
+	if typer.Ordered[1].Import.Path != "example.com/project/types" {
+		t.Fatal("unexpected import", typer.Ordered[1].Import.Path)
+	}
+}
+
+func TestTyper_dotImport(t *testing.T) {
+	typer := Typer{
+		Resolver: &godetector.Resolver{
+			Work: "off",
+			FS: godetector.NewFS(fstest.MapFS{
+				"project/go.mod":         {Data: []byte("module example.com/project\n")},
+				"project/model/model.go": {Data: []byte("package model\n\nimport . \"example.com/project/types\"\n\ntype User struct {\n\tID ID\n\tName string\n}\n")},
+				"project/types/types.go": {Data: []byte("package types\n\ntype ID struct {\n\tValue string\n}\n")},
+			}),
+		},
+	}
+	typer.AddFromDir("User", "/project/model")
+	if len(typer.Ordered) != 2 {
+		t.Fatal("should be 2 definitions but got", len(typer.Ordered))
+	}
+	field := typer.Ordered[0].StructFields()[0]
+	if field.Definition == nil || field.Definition.Import.Path != "example.com/project/types" {
+		t.Fatal("field type from dot-imported package is not resolved")
+	}
+}
@@ -0,0 +1,126 @@
+package godetector
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Get go environment variable with the same priority as go command: process environment,
+// user config file (GOENV, by default <user config dir>/go/env), GOROOT/go.env.
+func goEnv(key string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	if v := readEnvFile(userEnvFile())[key]; v != "" {
+		return v
+	}
+	if key == "GOROOT" {
+		return ""
+	}
+	return readEnvFile(filepath.Join(goRoot(), "go.env"))[key]
+}
+
+// GOROOT from environment, fallbacks to GOROOT of current runtime
+func goRoot() string {
+	if v := goEnv("GOROOT"); v != "" {
+		return v
+	}
+	return runtime.GOROOT()
+}
+
+// All GOPATH entries in order. Default is $HOME/go unless it is GOROOT
+func goPaths() []string {
+	var ans []string
+	for _, path := range filepath.SplitList(goEnv("GOPATH")) {
+		// go command ignores empty and rejects relative entries
+		if path != "" && filepath.IsAbs(path) {
+			ans = append(ans, path)
+		}
+	}
+	if len(ans) > 0 {
+		return ans
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	def := filepath.Join(home, "go")
+	if isRootOf(def, goRoot()) {
+		return nil
+	}
+	return []string{def}
+}
+
+// Location of modules cache: GOMODCACHE or pkg/mod in the first GOPATH entry
+func goModCache() string {
+	if v := goEnv("GOMODCACHE"); v != "" {
+		return v
+	}
+	paths := goPaths()
+	if len(paths) == 0 {
+		return ""
+	}
+	return filepath.Join(paths[0], "pkg", "mod")
+}
+
+// Flags from GOFLAGS variable
+func goFlags() []string {
+	return strings.Fields(goEnv("GOFLAGS"))
+}
+
+// Value of GOWORK variable: empty (auto-detect), off or path to go.work
+func goWork() string {
+	return goEnv("GOWORK")
+}
+
+// Patterns of modules which should not be fetched from proxy: GONOPROXY, by default GOPRIVATE
+func goNoProxy() string {
+	if v := goEnv("GONOPROXY"); v != "" {
+		return v
+	}
+	return goEnv("GOPRIVATE")
+}
+
+func userEnvFile() string {
+	if file := os.Getenv("GOENV"); file != "" {
+		if file == "off" {
+			return ""
+		}
+		return file
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "go", "env")
+}
+
+// Parse file in go env format: one KEY=VALUE per line. Returns nil for missed file
+func readEnvFile(file string) map[string]string {
+	if file == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	var ans = make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		eq := strings.Index(line, "=")
+		if eq <= 0 {
+			continue
+		}
+		ans[strings.TrimSpace(line[:eq])] = strings.TrimSpace(line[eq+1:])
+	}
+	return ans
+}
@@ -0,0 +1,38 @@
+package godetector
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGoEnv(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "godetector")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(tmp)
+	for _, key := range []string{"GOENV", "GOPATH", "GOMODCACHE", "GOFLAGS"} {
+		defer os.Setenv(key, os.Getenv(key))
+	}
+	writeFile(t, filepath.Join(tmp, "env"), "GOMODCACHE=/opt/modcache\nGOFLAGS=-mod=vendor\n")
+	os.Setenv("GOENV", filepath.Join(tmp, "env"))
+	os.Setenv("GOMODCACHE", "")
+	os.Setenv("GOFLAGS", "")
+	os.Setenv("GOPATH", "/opt/alfa"+string(filepath.ListSeparator)+"relative"+string(filepath.ListSeparator)+"/opt/beta")
+
+	assert.Equal(t, []string{"/opt/alfa", "/opt/beta"}, goPaths())
+	assert.Equal(t, "/opt/modcache", goModCache())
+	assert.Equal(t, []string{"-mod=vendor"}, goFlags())
+
+	// process environment has priority over config file
+	os.Setenv("GOMODCACHE", "/opt/other")
+	assert.Equal(t, "/opt/other", goModCache())
+
+	os.Setenv("GOENV", "off")
+	os.Setenv("GOMODCACHE", "")
+	assert.Equal(t, filepath.Join("/opt/alfa", "pkg", "mod"), goModCache())
+	assert.True(t, NewResolver().isGoPath("/opt/beta/src"))
+}
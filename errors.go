@@ -0,0 +1,201 @@
+package godetector
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/mod/modfile"
+	"strconv"
+	"strings"
+)
+
+// Directory can't be read
+type ErrUnreadableDir struct {
+	Dir string
+	Err error
+}
+
+func (e *ErrUnreadableDir) Error() string {
+	return "read directory " + e.Dir + ": " + e.Err.Error()
+}
+
+func (e *ErrUnreadableDir) Unwrap() error { return e.Err }
+
+// Directory has no go files matching build constraints
+type ErrNoGoFiles struct {
+	Dir string
+}
+
+func (e *ErrNoGoFiles) Error() string {
+	return "no buildable go source files in " + e.Dir
+}
+
+// Go files in directory define different packages
+type ErrConflictingPackages struct {
+	Dir   string
+	Names []string // package names
+	Files []string // file names in the same order as package names
+}
+
+func (e *ErrConflictingPackages) Error() string {
+	var defs = make([]string, 0, len(e.Names))
+	for i, name := range e.Names {
+		defs = append(defs, fmt.Sprintf("%s (%s)", name, e.Files[i]))
+	}
+	return "found packages " + strings.Join(defs, " and ") + " in " + e.Dir
+}
+
+// Package provided by several modules
+type ErrAmbiguousImport struct {
+	ImportPath string
+	Modules    []string // module@version
+	Dirs       []string // package directories in the same order as modules
+}
+
+func (e *ErrAmbiguousImport) Error() string {
+	var defs = make([]string, 0, len(e.Modules))
+	for i, mod := range e.Modules {
+		defs = append(defs, fmt.Sprintf("%s (%s)", mod, e.Dirs[i]))
+	}
+	return "ambiguous import: found package " + e.ImportPath + " in multiple modules: " + strings.Join(defs, ", ")
+}
+
+// Import violates visibility rules of internal or vendor directories
+type ErrNotVisible struct {
+	ImportPath string // imported package
+	From       string // directory of importing package
+	Rule       string // violated rule: internal or vendor
+	Boundary   string // only packages in this directory (and subdirectories) or, for modules, with this import path prefix can import the package
+}
+
+func (e *ErrNotVisible) Error() string {
+	return "use of " + e.Rule + " package " + e.ImportPath + " not allowed from " + e.From + ": only packages in " + e.Boundary + " can import it"
+}
+
+// Checksum of module (or its go.mod) doesn't match go.sum
+type ErrChecksumMismatch struct {
+	Module   string
+	Version  string
+	GoMod    bool   // checksum of go.mod file, otherwise checksum of module files
+	Expected string // checksum from go.sum. Empty if go.sum has no entry for module
+	Actual   string
+}
+
+func (e *ErrChecksumMismatch) Error() string {
+	what := e.Module + "@" + e.Version
+	if e.GoMod {
+		what += " go.mod"
+	}
+	if e.Expected == "" {
+		return "missing go.sum entry for " + what + " (" + e.Actual + ")"
+	}
+	return "checksum mismatch for " + what + ": go.sum has " + e.Expected + ", but downloaded " + e.Actual
+}
+
+// Package can't be found by import path in any location. Errors.Is(err, &ErrNotFound{}) matches any such error,
+// errors.Is and errors.As also check failure reasons of all locations
+type ErrNotFound struct {
+	ImportPath string
+	From       string   // work directory
+	Locations  []string // tried locations (like "GOROOT /usr/local/go/src/example.com/pkg")
+	Errs       []error  // failure reasons in the same order as locations
+}
+
+func (e *ErrNotFound) add(location string, err error) {
+	e.Locations = append(e.Locations, location)
+	e.Errs = append(e.Errs, err)
+}
+
+func (e *ErrNotFound) Error() string {
+	var tried = make([]string, 0, len(e.Locations))
+	for i, location := range e.Locations {
+		tried = append(tried, location+": "+e.Errs[i].Error())
+	}
+	return "package " + e.ImportPath + " not found from " + e.From + " (tried " + strings.Join(tried, "; ") + ")"
+}
+
+func (e *ErrNotFound) Is(target error) bool {
+	if _, ok := target.(*ErrNotFound); ok {
+		return true
+	}
+	for _, err := range e.Errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *ErrNotFound) As(target interface{}) bool {
+	for _, err := range e.Errs {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Path is not a package directory. Errors.Is(err, &ErrNotAPackage{}) matches any such error
+type ErrNotAPackage struct {
+	Dir    string
+	Reason string
+}
+
+func (e *ErrNotAPackage) Error() string {
+	return e.Dir + " is not a package directory: " + e.Reason
+}
+
+func (e *ErrNotAPackage) Is(target error) bool {
+	_, ok := target.(*ErrNotAPackage)
+	return ok
+}
+
+// Directory doesn't belong to any module, GOPATH or GOROOT. Errors.Is(err, &ErrNoModule{}) matches any such error
+type ErrNoModule struct {
+	Dir    string
+	Reason string // details, optional
+}
+
+func (e *ErrNoModule) Error() string {
+	msg := e.Dir + " is not in a module, GOPATH or GOROOT"
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
+
+func (e *ErrNoModule) Is(target error) bool {
+	_, ok := target.(*ErrNoModule)
+	return ok
+}
+
+// Go.mod (or go.work) file can't be parsed. Errors.Is(err, &ErrBadGoMod{}) matches any such error
+type ErrBadGoMod struct {
+	File   string
+	Line   int // position of the first error. Zero if unknown
+	Column int
+	Err    error
+}
+
+// Wrap parse error of modfile with position of the first error
+func badGoMod(file string, err error) error {
+	bad := &ErrBadGoMod{File: file, Err: err}
+	var list modfile.ErrorList
+	var single *modfile.Error
+	if errors.As(err, &list) && len(list) > 0 {
+		bad.Line, bad.Column = list[0].Pos.Line, list[0].Pos.LineRune
+	} else if errors.As(err, &single) {
+		bad.Line, bad.Column = single.Pos.Line, single.Pos.LineRune
+	}
+	return bad
+}
+
+func (e *ErrBadGoMod) Error() string {
+	return "bad go.mod " + e.File + ":" + strconv.Itoa(e.Line) + ":" + strconv.Itoa(e.Column) + ": " + e.Err.Error()
+}
+
+func (e *ErrBadGoMod) Unwrap() error { return e.Err }
+
+func (e *ErrBadGoMod) Is(target error) bool {
+	_, ok := target.(*ErrBadGoMod)
+	return ok
+}
@@ -0,0 +1,80 @@
+package godetector
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestResolver_typedErrors(t *testing.T) {
+	r := &Resolver{
+		GOROOT:   "/goroot",
+		GOPATH:   []string{"/gopath"},
+		ModCache: "/gopath/pkg/mod",
+		Work:     "off",
+		FS: NewFS(fstest.MapFS{
+			"goroot/src/fmt/print.go": {Data: []byte("package fmt\n")},
+			"project/go.mod":          {Data: []byte("module example.com/project\n")},
+			"project/main.go":         {Data: []byte("package main\n")},
+			"broken/go.mod":           {Data: []byte("module example.com/broken\n\nrequire (\n\texample.com/dep\n)\n")},
+			"broken/pkg/pkg.go":       {Data: []byte("package pkg\n")},
+			"outside/x.go":            {Data: []byte("package x\n")},
+		}),
+	}
+
+	_, err := r.InspectImport("example.com/missing", "/project")
+	var notFound *ErrNotFound
+	if assert.True(t, errors.As(err, &notFound)) {
+		assert.Equal(t, "example.com/missing", notFound.ImportPath)
+		assert.Equal(t, []string{
+			"GOROOT /goroot/src/example.com/missing",
+			"modules of /project/go.mod",
+			"GOPATH /gopath/src/example.com/missing",
+		}, notFound.Locations)
+	}
+	assert.True(t, errors.Is(err, &ErrNotFound{}))
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+
+	_, err = r.InspectDirectory("/outside")
+	assert.True(t, errors.Is(err, &ErrNoModule{}))
+	var noModule *ErrNoModule
+	if assert.True(t, errors.As(err, &noModule)) {
+		assert.Equal(t, "/outside", noModule.Dir)
+	}
+
+	_, err = r.InspectDirectory("/project/main.go")
+	assert.True(t, errors.Is(err, &ErrNotAPackage{}))
+
+	_, err = r.InspectDirectory("/broken/pkg")
+	var badGoMod *ErrBadGoMod
+	if assert.True(t, errors.As(err, &badGoMod)) {
+		assert.Equal(t, "/broken/go.mod", badGoMod.File)
+		assert.Equal(t, 4, badGoMod.Line)
+	}
+	assert.True(t, errors.Is(err, &ErrBadGoMod{}))
+	assert.False(t, errors.Is(err, &ErrNotFound{}))
+}
+
+func TestErrNotFound_reasons(t *testing.T) {
+	var err error = &ErrNotFound{
+		ImportPath: "example.com/pkg",
+		From:       "/project",
+		Locations:  []string{"GOPATH /gopath/src/example.com/pkg", "modules of /project/go.mod"},
+		Errs:       []error{fs.ErrNotExist, &ErrNoModule{Dir: "/gopath/src/example.com/pkg"}},
+	}
+	err = fmt.Errorf("wrapped: %w", err)
+	assert.True(t, errors.Is(err, &ErrNotFound{}))
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+	assert.True(t, errors.Is(err, &ErrNoModule{}))
+	assert.False(t, errors.Is(err, &ErrBadGoMod{}))
+
+	var noModule *ErrNoModule
+	if assert.True(t, errors.As(err, &noModule)) {
+		assert.Equal(t, "/gopath/src/example.com/pkg", noModule.Dir)
+	}
+	var badGoMod *ErrBadGoMod
+	assert.False(t, errors.As(err, &badGoMod))
+}
@@ -0,0 +1,225 @@
+package godetector
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"golang.org/x/mod/module"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Filesystem which supports writing. Required to fetch modules into modules cache (see Resolver.Fetch)
+type WritableFileSystem interface {
+	FileSystem
+	MkdirAll(name string, perm os.FileMode) error
+	WriteFile(name string, data []byte, perm os.FileMode) error
+	Rename(oldName, newName string) error
+	RemoveAll(name string) error
+}
+
+// Directories of file-based proxies which could provide module: file:// entries of GOPROXY list in order.
+// Entries separated by ',' and '|' are treated the same way, 'off' stops the list, network entries are skipped
+func (r *Resolver) proxyDirs(modPath string) []string {
+	if module.MatchPrefixPatterns(r.NoProxy, modPath) {
+		return nil
+	}
+	var dirs []string
+	for _, entry := range strings.FieldsFunc(r.Proxy, func(c rune) bool { return c == ',' || c == '|' }) {
+		entry = strings.TrimSpace(entry)
+		if entry == "off" {
+			break
+		}
+		u, err := url.Parse(entry)
+		if err != nil || u.Scheme != "file" {
+			continue
+		}
+		dirs = append(dirs, filepath.FromSlash(u.Path))
+	}
+	return dirs
+}
+
+func (r *Resolver) writableFS() (WritableFileSystem, error) {
+	var fsys = r.FS
+	if fsys == nil {
+		fsys = OSFileSystem{}
+	}
+	w, ok := fsys.(WritableFileSystem)
+	if !ok {
+		return nil, errors.New("filesystem of resolver doesn't support writing")
+	}
+	return w, nil
+}
+
+// Fetch go.mod of module version from proxy to download cache (<module>/@v/<version>.mod and .info), if it is missing.
+// Checksum of go.mod should be in go.sum files
+func (r *Resolver) fetchModFile(mod module.Version, sumFiles []string) error {
+	modFile, err := r.downloadCacheFile(mod, ".mod")
+	if err != nil {
+		return err
+	}
+	if _, err := r.fs().Stat(modFile); err == nil {
+		return nil
+	}
+	data, proxy, err := r.readFromProxy(mod, ".mod")
+	if err != nil {
+		return err
+	}
+	hash, err := hashModFile(data)
+	if err != nil {
+		return err
+	}
+	if err := r.readGoSums(sumFiles).check(mod, true, hash); err != nil {
+		return fmt.Errorf("fetch from %s: %w", proxy, err)
+	}
+	files := map[string][]byte{".mod": data}
+	if info, _, err := r.readFromProxy(mod, ".info"); err == nil {
+		files[".info"] = info
+	}
+	return r.writeDownloadFiles(mod, files)
+}
+
+// Fetch module version from proxy to modules cache if it is missing: go.mod and zip archive are verified by go.sum
+// files, saved to download cache and archive is extracted to <module>@<version>
+func (r *Resolver) fetchModule(mod module.Version, sumFiles []string) error {
+	dir, err := r.moduleCacheDir(mod)
+	if err != nil {
+		return err
+	}
+	if _, err := r.fs().Stat(dir); err == nil {
+		return nil
+	}
+	if err := r.fetchModFile(mod, sumFiles); err != nil {
+		return err
+	}
+	data, proxy, err := r.readFromProxy(mod, ".zip")
+	if err != nil {
+		return err
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("%s: invalid zip from %s: %w", mod, proxy, err)
+	}
+	hash, err := hashZip(archive)
+	if err != nil {
+		return err
+	}
+	if err := r.readGoSums(sumFiles).check(mod, false, hash); err != nil {
+		return fmt.Errorf("fetch from %s: %w", proxy, err)
+	}
+	if err := r.writeDownloadFiles(mod, map[string][]byte{".zip": data, ".ziphash": []byte(hash + "\n")}); err != nil {
+		return err
+	}
+	return r.extractModule(mod, archive, dir)
+}
+
+// Read file of module version from the first proxy which has it. Returns content and proxy directory
+func (r *Resolver) readFromProxy(mod module.Version, ext string) ([]byte, string, error) {
+	ep, err := module.EscapePath(mod.Path)
+	if err != nil {
+		return nil, "", err
+	}
+	ev, err := module.EscapeVersion(mod.Version)
+	if err != nil {
+		return nil, "", err
+	}
+	dirs := r.proxyDirs(mod.Path)
+	if len(dirs) == 0 {
+		return nil, "", fmt.Errorf("%s: no file-based proxy in GOPROXY for module", mod)
+	}
+	for _, dir := range dirs {
+		data, err := r.fs().ReadFile(filepath.Join(dir, filepath.FromSlash(ep), "@v", ev+ext))
+		if err == nil {
+			return data, dir, nil
+		}
+	}
+	return nil, "", fmt.Errorf("%s: %s not found in proxies %s", mod, ext, strings.Join(dirs, ", "))
+}
+
+func (r *Resolver) writeDownloadFiles(mod module.Version, files map[string][]byte) error {
+	fsys, err := r.writableFS()
+	if err != nil {
+		return err
+	}
+	for ext, data := range files {
+		file, err := r.downloadCacheFile(mod, ext)
+		if err != nil {
+			return err
+		}
+		if err := fsys.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return err
+		}
+		if err := fsys.WriteFile(file, data, 0644); err != nil {
+			return err
+		}
+		r.cache().Invalidate(file)
+	}
+	return nil
+}
+
+// Extract module archive to directory. All files in archive should be under <module>@<version>/ prefix.
+// Archive is extracted to temporary directory and moved into place, so partially extracted module is never visible
+func (r *Resolver) extractModule(mod module.Version, archive *zip.Reader, dir string) error {
+	fsys, err := r.writableFS()
+	if err != nil {
+		return err
+	}
+	tmp := fmt.Sprintf("%s.tmp-%d-%d", dir, os.Getpid(), time.Now().UnixNano())
+	if err := r.extractModuleTo(fsys, mod, archive, tmp); err != nil {
+		_ = fsys.RemoveAll(tmp)
+		return err
+	}
+	if err := fsys.Rename(tmp, dir); err != nil {
+		_ = fsys.RemoveAll(tmp)
+		// module could be extracted concurrently
+		if _, statErr := fsys.Stat(dir); statErr != nil {
+			return err
+		}
+	}
+	r.cache().Invalidate(dir)
+	return nil
+}
+
+func (r *Resolver) extractModuleTo(fsys WritableFileSystem, mod module.Version, archive *zip.Reader, dir string) error {
+	if err := fsys.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	prefix := mod.Path + "@" + mod.Version + "/"
+	for _, file := range archive.File {
+		if strings.HasSuffix(file.Name, "/") {
+			continue
+		}
+		name := strings.TrimPrefix(file.Name, prefix)
+		if name == file.Name || name == "" || path.Clean(name) != name || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("%s: unexpected file %s in module zip", mod, file.Name)
+		}
+		data, err := readZipFile(file)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := fsys.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := fsys.WriteFile(target, data, 0444); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readZipFile(file *zip.File) ([]byte, error) {
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var buf bytes.Buffer
+	_, err = buf.ReadFrom(f)
+	return buf.Bytes(), err
+}
@@ -0,0 +1,154 @@
+package godetector
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/mod/module"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Create module version in file-based proxy. Returns go.sum lines
+func writeProxyModule(t *testing.T, proxy, path, version string, files map[string]string) string {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(path + "@" + version + "/" + name)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		f.Write([]byte(content))
+	}
+	assert.NoError(t, w.Close())
+	escaped, err := module.EscapePath(path)
+	assert.NoError(t, err)
+	dir := filepath.Join(proxy, filepath.FromSlash(escaped), "@v")
+	writeFile(t, filepath.Join(dir, version+".mod"), files["go.mod"])
+	writeFile(t, filepath.Join(dir, version+".info"), `{"Version":"`+version+`"}`)
+	writeFile(t, filepath.Join(dir, version+".zip"), buf.String())
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+	zipHash, err := hashZip(archive)
+	assert.NoError(t, err)
+	modHash, err := hashModFile([]byte(files["go.mod"]))
+	assert.NoError(t, err)
+	return path + " " + version + " " + zipHash + "\n" + path + " " + version + "/go.mod " + modHash + "\n"
+}
+
+func TestResolver_Fetch(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "godetector")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(tmp)
+	defer os.Chmod(tmp, 0755)
+
+	proxy := filepath.Join(tmp, "proxy")
+	sums := writeProxyModule(t, proxy, "example.com/Dep", "v1.0.0", map[string]string{
+		"go.mod":     "module example.com/Dep\n\nrequire example.com/indirect v1.1.0\n",
+		"sub/sub.go": "package sub\n",
+	})
+	sums += writeProxyModule(t, proxy, "example.com/indirect", "v1.1.0", map[string]string{
+		"go.mod": "module example.com/indirect\n",
+		"x.go":   "package indirect\n",
+	})
+	project := filepath.Join(tmp, "project")
+	writeFile(t, filepath.Join(project, "go.mod"), "module example.com/project\n\nrequire example.com/Dep v1.0.0\n")
+	writeFile(t, filepath.Join(project, "go.sum"), sums)
+
+	newResolver := func() *Resolver {
+		return &Resolver{
+			ModCache: filepath.Join(tmp, "mod"),
+			Work:     "off",
+			Proxy:    "https://proxy.golang.org,file://" + filepath.ToSlash(proxy) + ",direct",
+		}
+	}
+
+	r := newResolver()
+	_, err = r.InspectImport("example.com/Dep/sub", project)
+	assert.Error(t, err, "fetching is opt-in")
+
+	r = newResolver()
+	r.Fetch = true
+	r.NoProxy = "example.com"
+	_, err = r.InspectImport("example.com/Dep/sub", project)
+	assert.Error(t, err, "module matches GONOPROXY")
+
+	r = newResolver()
+	r.Fetch = true
+	info, err := r.InspectImport("example.com/Dep/sub", project)
+	if assert.NoError(t, err) {
+		assert.Equal(t, filepath.Join(tmp, "mod", "example.com", "!dep@v1.0.0", "sub"), info.ImportDir)
+		assert.Equal(t, GoCache, info.LocationType)
+	}
+	assert.FileExists(t, filepath.Join(tmp, "mod", "cache", "download", "example.com", "!dep", "@v", "v1.0.0.zip"))
+	assert.FileExists(t, filepath.Join(tmp, "mod", "cache", "download", "example.com", "indirect", "@v", "v1.1.0.mod"), "go.mod of dependencies is fetched for version selection")
+
+	// checksum of module doesn't match go.sum
+	writeFile(t, filepath.Join(project, "go.sum"), sums+"example.com/other v1.0.0 h1:AAAA=\n")
+	writeFile(t, filepath.Join(project, "go.mod"), "module example.com/project\n\nrequire (\n\texample.com/Dep v1.0.0\n\texample.com/other v1.0.0\n)\n")
+	writeProxyModule(t, proxy, "example.com/other", "v1.0.0", map[string]string{
+		"go.mod": "module example.com/other\n",
+		"o.go":   "package other\n",
+	})
+	r = newResolver()
+	r.Fetch = true
+	_, err = r.InspectImport("example.com/other", project)
+	var mismatch *ErrChecksumMismatch
+	if assert.True(t, errors.As(err, &mismatch)) {
+		assert.Equal(t, "example.com/other", mismatch.Module)
+		assert.True(t, mismatch.GoMod)
+	}
+}
+
+func TestResolver_Fetch_extract(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "godetector")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(tmp)
+
+	proxy := filepath.Join(tmp, "proxy")
+	sums := writeProxyModule(t, proxy, "example.com/dirs", "v1.0.0", map[string]string{
+		"go.mod":     "module example.com/dirs\n",
+		"sub/":       "",
+		"sub/sub.go": "package sub\n",
+	})
+	sums += writeProxyModule(t, proxy, "example.com/bad", "v1.0.0", map[string]string{
+		"go.mod":     "module example.com/bad\n",
+		"bad.go":     "package bad\n",
+		"../evil.go": "package evil\n",
+	})
+	project := filepath.Join(tmp, "project")
+	writeFile(t, filepath.Join(project, "go.mod"), "module example.com/project\n\nrequire (\n\texample.com/dirs v1.0.0\n\texample.com/bad v1.0.0\n)\n")
+	writeFile(t, filepath.Join(project, "go.sum"), sums)
+
+	r := &Resolver{
+		ModCache: filepath.Join(tmp, "mod"),
+		Work:     "off",
+		Proxy:    "file://" + filepath.ToSlash(proxy),
+		Fetch:    true,
+	}
+	info, err := r.InspectImport("example.com/dirs/sub", project)
+	if assert.NoError(t, err, "directory entries of zip should be skipped") {
+		assert.Equal(t, filepath.Join(tmp, "mod", "example.com", "dirs@v1.0.0", "sub"), info.ImportDir)
+	}
+
+	_, err = r.InspectImport("example.com/bad", project)
+	assert.Error(t, err)
+	_, err = r.InspectImport("example.com/bad", project)
+	assert.Error(t, err, "partially extracted module should not be used")
+	files, err := ioutil.ReadDir(filepath.Join(tmp, "mod", "example.com"))
+	if assert.NoError(t, err) {
+		var names []string
+		for _, file := range files {
+			names = append(names, file.Name())
+		}
+		assert.Equal(t, []string{"dirs@v1.0.0"}, names)
+	}
+}
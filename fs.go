@@ -0,0 +1,212 @@
+package godetector
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Create filesystem backed by io/fs. Absolute paths are mapped to slash-separated paths relative to the root of fsys:
+// /opt/go/src/fmt will be opened as opt/go/src/fmt
+func NewFS(fsys fs.FS) FileSystem {
+	return &ioFS{fsys: fsys}
+}
+
+type ioFS struct {
+	fsys fs.FS
+}
+
+func (f *ioFS) Stat(name string) (os.FileInfo, error) {
+	return fs.Stat(f.fsys, f.name(name))
+}
+
+func (f *ioFS) ReadDir(name string) ([]os.FileInfo, error) {
+	entries, err := fs.ReadDir(f.fsys, f.name(name))
+	if err != nil {
+		return nil, err
+	}
+	var ans = make([]os.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		ans = append(ans, info)
+	}
+	return ans, nil
+}
+
+func (f *ioFS) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(f.fsys, f.name(name))
+}
+
+func (f *ioFS) name(path string) string {
+	path = filepath.Clean(path)
+	path = path[len(filepath.VolumeName(path)):]
+	path = strings.TrimPrefix(filepath.ToSlash(path), "/")
+	if path == "" {
+		return "."
+	}
+	return path
+}
+
+// Create filesystem which replaces content of files like 'go build -overlay' does. Keys are absolute file paths,
+// nil content means that file is deleted. Files could be added to directories that don't exist in base filesystem.
+func NewOverlayFS(base FileSystem, files map[string][]byte) FileSystem {
+	var overlay = make(map[string][]byte, len(files))
+	for name, content := range files {
+		overlay[filepath.Clean(name)] = content
+	}
+	return &overlayFS{base: base, files: overlay}
+}
+
+type overlayFS struct {
+	base  FileSystem
+	files map[string][]byte
+}
+
+func (o *overlayFS) Stat(name string) (os.FileInfo, error) {
+	name = filepath.Clean(name)
+	if content, ok := o.files[name]; ok {
+		if content == nil {
+			return nil, &os.PathError{Op: "stat", Path: name, Err: os.ErrNotExist}
+		}
+		return &overlayFileInfo{name: filepath.Base(name), size: int64(len(content))}, nil
+	}
+	info, err := o.base.Stat(name)
+	if err != nil && o.hasChildren(name) {
+		return &overlayFileInfo{name: filepath.Base(name), dir: true}, nil
+	}
+	return info, err
+}
+
+func (o *overlayFS) ReadDir(name string) ([]os.FileInfo, error) {
+	name = filepath.Clean(name)
+	var indexed = make(map[string]os.FileInfo)
+	list, err := o.base.ReadDir(name)
+	if err != nil && !o.hasChildren(name) {
+		return nil, err
+	}
+	for _, info := range list {
+		indexed[info.Name()] = info
+	}
+	for file, content := range o.files {
+		rel, err := filepath.Rel(name, file)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		parts := strings.SplitN(rel, string(filepath.Separator), 2)
+		if len(parts) > 1 {
+			// file in sub directory
+			if _, ok := indexed[parts[0]]; !ok {
+				indexed[parts[0]] = &overlayFileInfo{name: parts[0], dir: true}
+			}
+			continue
+		}
+		if content == nil {
+			delete(indexed, rel)
+		} else {
+			indexed[rel] = &overlayFileInfo{name: rel, size: int64(len(content))}
+		}
+	}
+	var ans = make([]os.FileInfo, 0, len(indexed))
+	for _, info := range indexed {
+		ans = append(ans, info)
+	}
+	sort.Slice(ans, func(i, j int) bool {
+		return ans[i].Name() < ans[j].Name()
+	})
+	return ans, nil
+}
+
+func (o *overlayFS) ReadFile(name string) ([]byte, error) {
+	name = filepath.Clean(name)
+	if content, ok := o.files[name]; ok {
+		if content == nil {
+			return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+		}
+		return content, nil
+	}
+	return o.base.ReadFile(name)
+}
+
+func (o *overlayFS) hasChildren(dir string) bool {
+	for file, content := range o.files {
+		if content != nil && relatesToPackage(filepath.ToSlash(dir), filepath.ToSlash(filepath.Dir(file))) {
+			return true
+		}
+	}
+	return false
+}
+
+type overlayFileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (ofi *overlayFileInfo) Name() string { return ofi.name }
+
+func (ofi *overlayFileInfo) Size() int64 { return ofi.size }
+
+func (ofi *overlayFileInfo) Mode() os.FileMode {
+	if ofi.dir {
+		return os.ModeDir | 0555
+	}
+	return 0444
+}
+
+func (ofi *overlayFileInfo) ModTime() time.Time { return time.Time{} }
+
+func (ofi *overlayFileInfo) IsDir() bool { return ofi.dir }
+
+func (ofi *overlayFileInfo) Sys() interface{} { return nil }
+
+// Parse go source file through resolver filesystem
+func (r *Resolver) ParseFile(fset *token.FileSet, filename string, mode parser.Mode) (*ast.File, error) {
+	src, err := r.fs().ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return parser.ParseFile(fset, filename, src, mode)
+}
+
+// Parse go files in directory through resolver filesystem. Works the same way as parser.ParseDir
+func (r *Resolver) ParseDir(fset *token.FileSet, dir string, filter func(os.FileInfo) bool, mode parser.Mode) (map[string]*ast.Package, error) {
+	list, err := r.fs().ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var pkgs = make(map[string]*ast.Package)
+	var firstErr error
+	for _, info := range list {
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ".go") || (filter != nil && !filter(info)) {
+			continue
+		}
+		filename := filepath.Join(dir, info.Name())
+		file, err := r.ParseFile(fset, filename, mode)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		name := file.Name.Name
+		pkg, ok := pkgs[name]
+		if !ok {
+			pkg = &ast.Package{
+				Name:  name,
+				Files: make(map[string]*ast.File),
+			}
+			pkgs[name] = pkg
+		}
+		pkg.Files[filename] = file
+	}
+	return pkgs, firstErr
+}
@@ -0,0 +1,105 @@
+package godetector
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go/token"
+	"testing"
+	"testing/fstest"
+)
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"goroot/src/fmt/print.go":                          {Data: []byte("package fmt\n")},
+		"gopath/src/example.com/legacy/legacy.go":          {Data: []byte("package legacy\n")},
+		"gopath/pkg/mod/example.com/dep@v1.0.0/go.mod":     {Data: []byte("module example.com/dep\n")},
+		"gopath/pkg/mod/example.com/dep@v1.0.0/sub/sub.go": {Data: []byte("package sub\n")},
+		"project/go.mod":                                   {Data: []byte("module example.com/project\n\nrequire example.com/dep v1.0.0\n")},
+		"project/internal/alfa/alfa.go":                    {Data: []byte("package alfa\n")},
+		"vendored/go.mod":                                  {Data: []byte("module example.com/vendored\n\ngo 1.14\n\nrequire example.com/dep v1.0.0\n")},
+		"vendored/vendor/modules.txt":                      {Data: []byte("# example.com/dep v1.0.0\n## explicit\nexample.com/dep/sub\n")},
+		"vendored/vendor/example.com/dep/sub/sub.go":       {Data: []byte("package vsub\n")},
+	}
+}
+
+func testResolver() *Resolver {
+	return &Resolver{
+		GOROOT:   "/goroot",
+		GOPATH:   []string{"/gopath"},
+		ModCache: "/gopath/pkg/mod",
+		Work:     "off",
+		FS:       NewFS(testFS()),
+	}
+}
+
+func TestResolver_FS_module(t *testing.T) {
+	r := testResolver()
+	info, err := r.InspectImport("example.com/dep/sub", "/project")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, GoCache, info.LocationType)
+	assert.Equal(t, "/gopath/pkg/mod/example.com/dep@v1.0.0/sub", info.ImportDir)
+	assert.Equal(t, "sub", r.FindPackageNameByDir(info.ImportDir))
+
+	imp, err := r.InspectImportByDir("/project/internal/alfa")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "example.com/project/internal/alfa", imp.Path)
+	assert.Equal(t, "alfa", imp.Package)
+	assert.Equal(t, GoMod, imp.Type)
+
+	info, err = r.InspectImport("fmt", "/project")
+	if assert.NoError(t, err) {
+		assert.Equal(t, GoRoot, info.LocationType)
+	}
+}
+
+func TestResolver_FS_gopath(t *testing.T) {
+	r := testResolver()
+	info, err := r.InspectImport("example.com/legacy", "/gopath/src/example.com/legacy")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, GoPath, info.LocationType)
+	assert.Equal(t, "/gopath/src/example.com/legacy", info.ImportDir)
+	path, err := r.FindImportPath("/gopath/src/example.com/legacy")
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com/legacy", path)
+}
+
+func TestResolver_FS_vendor(t *testing.T) {
+	r := testResolver()
+	info, err := r.InspectImport("example.com/dep/sub", "/vendored")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, InLocalVendor, info.LocationType)
+	assert.Equal(t, "/vendored/vendor/example.com/dep/sub", info.ImportDir)
+	assert.Equal(t, "vsub", r.FindPackageNameByDir(info.ImportDir))
+}
+
+func TestResolver_FS_overlay(t *testing.T) {
+	r := testResolver()
+	r.Overlay = map[string][]byte{
+		"/project/internal/alfa/alfa.go": nil,
+		"/project/internal/alfa/beta.go": []byte("package beta\n"),
+		"/project/gamma/gamma.go":        []byte("package gamma\n\ntype Gamma int\n"),
+	}
+	assert.Equal(t, "beta", r.FindPackageNameByDir("/project/internal/alfa"))
+
+	imp, err := r.InspectImportByDir("/project/gamma")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "example.com/project/gamma", imp.Path)
+	assert.Equal(t, "gamma", imp.Package)
+
+	var fs token.FileSet
+	pkgs, err := r.ParseDir(&fs, "/project/gamma", nil, 0)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, pkgs, "gamma")
+	assert.Contains(t, pkgs["gamma"].Files, "/project/gamma/gamma.go")
+}
@@ -1,9 +1,9 @@
 module github.com/reddec/godetector
 
-go 1.13
+go 1.16
 
 require (
 	github.com/fatih/structtag v1.2.0
 	github.com/stretchr/testify v1.5.1
-	golang.org/x/mod v0.2.0
+	golang.org/x/mod v0.12.0
 )
@@ -0,0 +1,202 @@
+package godetector
+
+import (
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+type NodeKind int
+
+const (
+	NodeStd        NodeKind = 0 // standard library package
+	NodeMain       NodeKind = 1 // package of main module or workspace module
+	NodeDependency NodeKind = 2 // package of required module (modules cache or replacement)
+	NodeVendored   NodeKind = 3 // package from vendor directory
+	NodeGoPath     NodeKind = 4 // package from GOPATH
+	NodeUnresolved NodeKind = 5 // import which can't be resolved
+)
+
+// Package import graph. Standard library packages are leaves: their imports are not inspected
+type Graph struct {
+	Root  string                // import path of start package
+	Nodes map[string]*GraphNode // nodes by import path
+}
+
+// Package in import graph
+type GraphNode struct {
+	Path    string // import path
+	Kind    NodeKind
+	Import  *Import  // resolved package. Nil for unresolved imports
+	Imports []string // sorted unique import paths of package (excluding test files)
+	Err     error    // resolution or parsing error
+}
+
+// Build import graph of package in directory (see Resolver.BuildGraph)
+func BuildGraph(dir string) (*Graph, error) {
+	return DefaultResolver().BuildGraph(dir)
+}
+
+// Build import graph of package in directory: imports of go files matching build constraints are resolved by
+// InspectImport and inspected recursively. Imports of main module (and GOPATH) packages are resolved from their
+// directories, imports of dependencies - from start directory, so selected versions of main module are used.
+// Unresolved imports and parse errors are saved to nodes, error returned only if start directory can't be inspected
+func (r *Resolver) BuildGraph(dir string) (*Graph, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	root, err := r.InspectImportByDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	graph := &Graph{Root: root.Path, Nodes: make(map[string]*GraphNode)}
+	queue := []*GraphNode{{Path: root.Path, Kind: nodeKind(root), Import: root}}
+	graph.Nodes[root.Path] = queue[0]
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		if node.Import == nil || node.Kind == NodeStd {
+			continue
+		}
+		node.Imports, node.Err = r.packageImports(node.Import.Location)
+		workDir := dir
+		if node.Kind == NodeMain || node.Kind == NodeGoPath {
+			workDir = node.Import.Location
+		}
+		for _, importPath := range node.Imports {
+			if _, ok := graph.Nodes[importPath]; ok {
+				continue
+			}
+			child := &GraphNode{Path: importPath, Kind: NodeUnresolved}
+			if importPath == "unsafe" {
+				// pseudo-package without sources
+				child.Kind = NodeStd
+			} else if info, err := r.InspectImport(importPath, workDir); err != nil {
+				child.Err = err
+			} else {
+				child.Import = r.toImport(info)
+				child.Kind = nodeKind(child.Import)
+			}
+			graph.Nodes[importPath] = child
+			queue = append(queue, child)
+		}
+	}
+	return graph, nil
+}
+
+// Sorted unique imports of package in directory. Cgo pseudo-import "C" is skipped
+func (r *Resolver) packageImports(dir string) ([]string, error) {
+	var fs token.FileSet
+	pkgs, err := r.ParseDir(&fs, dir, r.buildFilter(dir), parser.ImportsOnly)
+	var unique = make(map[string]bool)
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, spec := range file.Imports {
+				path, _ := strconv.Unquote(spec.Path.Value)
+				if path != "" && path != "C" {
+					unique[path] = true
+				}
+			}
+		}
+	}
+	var ans = make([]string, 0, len(unique))
+	for path := range unique {
+		ans = append(ans, path)
+	}
+	sort.Strings(ans)
+	return ans, err
+}
+
+func nodeKind(imp *Import) NodeKind {
+	switch {
+	case imp.Type == GoRoot:
+		return NodeStd
+	case imp.Type == InLocalVendor:
+		return NodeVendored
+	case imp.Type == GoPath:
+		return NodeGoPath
+	case imp.Module != nil && imp.Module.Main:
+		return NodeMain
+	case imp.Type == GoMod || imp.Type == GoWork:
+		return NodeMain
+	}
+	return NodeDependency
+}
+
+// Import cycles in graph. Each cycle is a list of import paths where the first package is repeated at the end
+func (g *Graph) Cycles() [][]string {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	var state = make(map[string]int)
+	var stack []string
+	var cycles [][]string
+	var visit func(path string)
+	visit = func(path string) {
+		state[path] = visiting
+		stack = append(stack, path)
+		if node, ok := g.Nodes[path]; ok {
+			for _, child := range node.Imports {
+				switch state[child] {
+				case visiting:
+					for i := len(stack) - 1; i >= 0; i-- {
+						if stack[i] == child {
+							cycle := append(append([]string(nil), stack[i:]...), child)
+							cycles = append(cycles, cycle)
+							break
+						}
+					}
+				case 0:
+					visit(child)
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[path] = done
+	}
+	for _, path := range append([]string{g.Root}, g.paths()...) {
+		if state[path] == 0 {
+			visit(path)
+		}
+	}
+	return cycles
+}
+
+// Import paths of all packages in graph, dependencies before packages which import them. Order of packages in
+// import cycles is undefined
+func (g *Graph) Sorted() []string {
+	keys := g.paths()
+	var visited = make(map[string]bool)
+	var ans = make([]string, 0, len(keys))
+	var visit func(path string)
+	visit = func(path string) {
+		if visited[path] {
+			return
+		}
+		visited[path] = true
+		if node, ok := g.Nodes[path]; ok {
+			for _, child := range node.Imports {
+				visit(child)
+			}
+		}
+		ans = append(ans, path)
+	}
+	for _, path := range keys {
+		visit(path)
+	}
+	return ans
+}
+
+// Sorted import paths of nodes
+func (g *Graph) paths() []string {
+	var keys = make([]string, 0, len(g.Nodes))
+	for path := range g.Nodes {
+		keys = append(keys, path)
+	}
+	sort.Strings(keys)
+	return keys
+}
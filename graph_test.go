@@ -0,0 +1,45 @@
+package godetector
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"testing/fstest"
+)
+
+func TestResolver_BuildGraph(t *testing.T) {
+	r := &Resolver{
+		GOROOT:   "/goroot",
+		ModCache: "/gopath/pkg/mod",
+		Work:     "off",
+		FS: NewFS(fstest.MapFS{
+			"goroot/src/fmt/print.go":                      {Data: []byte("package fmt\n\nimport \"errors\"\n")},
+			"goroot/src/errors/errors.go":                  {Data: []byte("package errors\n")},
+			"project/go.mod":                               {Data: []byte("module example.com/project\n\nrequire example.com/dep v1.0.0\n")},
+			"project/main.go":                              {Data: []byte("package main\n\nimport (\n\t\"fmt\"\n\t\"example.com/project/alfa\"\n)\n")},
+			"project/main_test.go":                         {Data: []byte("package main\n\nimport \"example.com/testonly\"\n")},
+			"project/alfa/alfa.go":                         {Data: []byte("package alfa\n\nimport (\n\t\"unsafe\"\n\t\"example.com/dep\"\n\t\"example.com/missing\"\n)\n")},
+			"project/alfa/alfa_other.go":                   {Data: []byte("//go:build ignore\n\npackage alfa\n\nimport \"example.com/ignored\"\n")},
+			"gopath/pkg/mod/example.com/dep@v1.0.0/go.mod": {Data: []byte("module example.com/dep\n")},
+			"gopath/pkg/mod/example.com/dep@v1.0.0/dep.go": {Data: []byte("package dep\n\nimport \"example.com/project/alfa\"\n")},
+		}),
+	}
+	graph, err := r.BuildGraph("/project")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "example.com/project", graph.Root)
+	assert.Equal(t, []string{"example.com/project/alfa", "fmt"}, graph.Nodes["example.com/project"].Imports)
+	assert.Equal(t, NodeMain, graph.Nodes["example.com/project/alfa"].Kind)
+	assert.Equal(t, NodeDependency, graph.Nodes["example.com/dep"].Kind)
+	assert.Equal(t, NodeStd, graph.Nodes["fmt"].Kind)
+	assert.Equal(t, NodeStd, graph.Nodes["unsafe"].Kind)
+	assert.Equal(t, NodeUnresolved, graph.Nodes["example.com/missing"].Kind)
+	assert.Error(t, graph.Nodes["example.com/missing"].Err)
+	assert.NotContains(t, graph.Nodes, "errors", "standard library packages are leaves")
+	assert.NotContains(t, graph.Nodes, "example.com/testonly")
+	assert.NotContains(t, graph.Nodes, "example.com/ignored")
+
+	assert.Equal(t, [][]string{{"example.com/project/alfa", "example.com/dep", "example.com/project/alfa"}}, graph.Cycles())
+	sorted := graph.Sorted()
+	assert.Equal(t, "example.com/project", sorted[len(sorted)-1])
+}
@@ -2,90 +2,507 @@ package godetector
 
 import (
 	"errors"
-	"go/build"
+	"fmt"
 	"golang.org/x/mod/modfile"
 	"golang.org/x/mod/module"
-	"io/ioutil"
+	"golang.org/x/mod/semver"
 	"path/filepath"
-	"runtime"
+	"sort"
 	"strings"
 )
 
 // Find package definition with respect to gomodules
 func FindPackageDefinitionDir(importPath string, workDir string) (string, error) {
-	info, err := InspectImport(importPath, workDir)
+	return DefaultResolver().FindPackageDefinitionDir(importPath, workDir)
+}
+
+// Find package definition using resolver
+func (r *Resolver) FindPackageDefinitionDir(importPath string, workDir string) (string, error) {
+	info, err := r.InspectImport(importPath, workDir)
 	if err != nil {
 		return "", err
 	}
 	return info.ImportDir, err
 }
 
+// Inspect import path as it imported from work directory: GOROOT, go modules (including workspace, replacements and vendor) and GOPATH
 func InspectImport(importPath string, workDir string) (info *importPathInfo, err error) {
+	return DefaultResolver().InspectImport(importPath, workDir)
+}
+
+// Inspect import path as it imported from work directory using resolver
+func (r *Resolver) InspectImport(importPath string, workDir string) (info *importPathInfo, err error) {
 	abs, err := filepath.Abs(workDir)
 	if err != nil {
 		return nil, err
 	}
+	info, err = r.inspectImportCached(importPath, abs)
+	if err != nil || !r.Strict {
+		return info, err
+	}
+	if err := r.checkVisibility(abs, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
 
-	rootInfo, err := InspectDirectory(abs)
+// Inspect import from absolute work directory. Examined candidates are recorded to trace if it is not nil
+func (r *Resolver) inspectImport(importPath string, abs string, trace *Trace) (info *importPathInfo, err error) {
+	rootInfo, err := r.InspectDirectory(abs)
 	if err != nil {
 		return nil, err
 	}
 
+	ws, err := r.findWorkspace(abs)
+	if err != nil {
+		return nil, err
+	}
+
+	var nestedDir string // package directory inside nested module of main (or workspace) module
 	if rootInfo.LocationType == GoMod {
-		rootImport, err := rootInfo.Root()
+		rootImport, err := r.InspectImportByDir(rootInfo.PackageRootDir)
 		if err != nil {
 			return nil, err
 		}
 		if relatesToPackage(rootImport.Path, importPath) {
-			childDir := importPath[len(rootImport.Path):]
-			return &importPathInfo{
-				PackageRootDir: rootInfo.PackageRootDir,
-				LocationType:   rootInfo.LocationType,
-				ImportDir:      filepath.Join(rootInfo.PackageRootDir, childDir),
-				Import:         importPath,
-			}, nil
+			dir, nested := r.localModulePackage(rootInfo.PackageRootDir, rootImport.Path, importPath)
+			if !nested {
+				trace.add(RuleMainModule, dir, true, "import path is in main module "+rootImport.Path)
+				return &importPathInfo{
+					PackageRootDir: rootInfo.PackageRootDir,
+					LocationType:   rootInfo.LocationType,
+					ImportDir:      dir,
+					Import:         importPath,
+					Module:         rootInfo.Module,
+				}, nil
+			}
+			trace.add(RuleMainModule, dir, false, "directory belongs to nested module of "+rootImport.Path)
+			nestedDir = dir
+		} else {
+			trace.add(RuleMainModule, rootInfo.PackageRootDir, false, "import path is outside of main module "+rootImport.Path)
 		}
 	}
 
-	// check GOROOT
-	if info, err := inspectDirectory(filepath.Join(runtime.GOROOT(), "src", importPath)); err == nil {
-		return info, nil
+	// check other modules of workspace
+	if ws != nil {
+		if mod := ws.findModule(importPath); mod != nil {
+			dir, nested := r.localModulePackage(mod.Dir, mod.Path, importPath)
+			if !nested {
+				trace.add(RuleWorkspace, dir, true, "import path is in workspace module "+mod.Path+" of "+ws.File)
+				locationType := GoWork
+				if mod.Dir == rootInfo.PackageRootDir {
+					locationType = GoMod
+				}
+				module := r.moduleOf(mod.Dir)
+				if module == nil {
+					module = &Module{Path: mod.Path, Dir: mod.Dir}
+				}
+				module.Main = true
+				module.Workspace = true
+				return &importPathInfo{
+					PackageRootDir: mod.Dir,
+					LocationType:   locationType,
+					ImportDir:      dir,
+					Import:         importPath,
+					Module:         module,
+				}, nil
+			}
+			trace.add(RuleWorkspace, dir, false, "directory belongs to nested module of workspace module "+mod.Path)
+			nestedDir = dir
+		} else {
+			trace.add(RuleWorkspace, ws.File, false, "no workspace module provides import path")
+		}
+	}
+
+	// nested module is a separate module: use required version (or replacement), otherwise local checkout
+	if nestedDir != "" {
+		info, err := r.findPackagePathInModules(importPath, rootInfo.PackageRootDir, ws, trace)
+		if err == nil {
+			return info, nil
+		}
+		if stopsLookup(err) {
+			return nil, err
+		}
+		trace.add(RuleMainModule, nestedDir, true, "nested module is not required, local directory is used")
+		return r.inspectDirectory(nestedDir)
+	}
+
+	// check GOROOT (if it is known)
+	notFound := &ErrNotFound{ImportPath: importPath, From: abs}
+	if r.GOROOT != "" {
+		goRootDir := filepath.Join(r.GOROOT, "src", importPath)
+		info, err = r.inspectDirectory(goRootDir)
+		if err == nil {
+			trace.add(RuleGoRoot, goRootDir, true, "standard library package")
+			return info, nil
+		}
+		trace.reject(RuleGoRoot, goRootDir, err)
+		notFound.add("GOROOT "+goRootDir, err)
+	}
+	// standard library and commands always use own vendor directories
+	if rootInfo.LocationType == GoRoot {
+		vendorRoot := filepath.Join(r.GOROOT, "src")
+		if relatesToPackage("cmd", rootInfo.Import) {
+			vendorRoot = filepath.Join(vendorRoot, "cmd")
+		}
+		info, err := r.findPackageInVendor(importPath, vendorRoot)
+		if err == nil {
+			trace.add(RuleVendor, info.ImportDir, true, "package is vendored in GOROOT")
+			return info, nil
+		}
+		trace.reject(RuleVendor, filepath.Join(vendorRoot, "vendor"), err)
+		notFound.add("GOROOT vendor "+filepath.Join(vendorRoot, "vendor"), err)
+	}
+	// check vendor directory instead of modules cache in vendor mode
+	vendorMode := rootInfo.LocationType == GoMod && ws == nil && r.isVendorMode(rootInfo.PackageRootDir)
+	if vendorMode {
+		info, err := r.findPackageInVendor(importPath, rootInfo.PackageRootDir)
+		if err == nil {
+			trace.add(RuleVendor, info.ImportDir, true, "vendor mode, package is listed in vendor/modules.txt")
+			return info, nil
+		}
+		trace.reject(RuleVendor, filepath.Join(rootInfo.PackageRootDir, "vendor"), err)
+		notFound.add("vendor "+filepath.Join(rootInfo.PackageRootDir, "vendor"), err)
 	}
 	// check local modules if applicable
-	if rootInfo.LocationType == GoMod {
-		if dir, err := findPackagePathInModules(importPath, rootInfo.PackageRootDir); err == nil {
-			return inspectDirectory(dir)
+	if !vendorMode && (rootInfo.LocationType == GoMod || ws != nil) {
+		info, err := r.findPackagePathInModules(importPath, rootInfo.PackageRootDir, ws, trace)
+		if err == nil {
+			return info, nil
+		}
+		if stopsLookup(err) {
+			return nil, err
+		}
+		location := "modules of " + filepath.Join(rootInfo.PackageRootDir, "go.mod")
+		if ws != nil {
+			location = "modules of " + ws.File
 		}
+		notFound.add(location, err)
 	}
-	// check GOPATH
-	return inspectDirectory(filepath.Join(build.Default.GOPATH, "src", importPath))
+	// check vendor directories of GOPATH project from work directory up to GOPATH/src
+	if rootInfo.LocationType == GoPath {
+		info, err := r.findPackageInGoPathVendor(importPath, abs, rootInfo.PackageRootDir)
+		if err == nil {
+			trace.add(RuleVendor, info.ImportDir, true, "package is in vendor directory of GOPATH project")
+			return info, nil
+		}
+		trace.reject(RuleVendor, abs, err)
+		notFound.add("vendor directories of "+abs, err)
+	}
+	// check every GOPATH entry in order
+	if len(r.GOPATH) == 0 {
+		err := errors.New("GOPATH is not defined")
+		trace.reject(RuleGoPath, "", err)
+		notFound.add("GOPATH", err)
+	}
+	for _, gopath := range r.GOPATH {
+		dir := filepath.Join(gopath, "src", importPath)
+		info, err := r.inspectDirectory(dir)
+		if err == nil {
+			trace.add(RuleGoPath, dir, true, "package is in GOPATH entry "+gopath)
+			return info, nil
+		}
+		trace.reject(RuleGoPath, dir, err)
+		notFound.add("GOPATH "+dir, err)
+	}
+	return nil, notFound
 }
 
-func findPackagePathInModules(importPath, modProjectDir string) (string, error) {
-	path := filepath.Join(modProjectDir, "go.mod")
-	data, err := ioutil.ReadFile(path)
-	if err != nil {
-		return "", err
+// Map import path to directory of local module. Returns true if package belongs to nested module: one of directories
+// between module root and package directory (inclusive) has own go.mod
+func (r *Resolver) localModulePackage(modDir, modPath, importPath string) (string, bool) {
+	childDir := tail(modPath, importPath)
+	dir := modDir
+	var nested bool
+	for _, elem := range strings.Split(childDir, "/") {
+		if elem == "" {
+			continue
+		}
+		dir = filepath.Join(dir, elem)
+		if _, ok := r.hasModFile(dir); ok {
+			nested = true
+		}
+	}
+	return dir, nested
+}
+
+// Requirements and replacements visible from main module or workspace
+type moduleSet struct {
+	Main        []string           // paths of main modules
+	Files       []string           // go.mod files of main modules
+	Sums        []string           // go.sum files of main modules and go.work.sum of workspace
+	Origin      map[string]string  // main module path by path of required module
+	Require     []module.Version   // requirements of all main modules, one (highest) version per module path
+	Replace     []*modfile.Replace // replacements from go.mod files with absolute filesystem paths
+	WorkReplace []*modfile.Replace // workspace-level replacements, override replacements from go.mod files
+}
+
+// Load requirements of main module, or of every workspace module if workspace defined
+func (r *Resolver) loadModuleSet(modProjectDir string, ws *workspace) (*moduleSet, error) {
+	var dirs = []string{modProjectDir}
+	var set = moduleSet{Origin: make(map[string]string)}
+	if ws != nil {
+		dirs = nil
+		for _, mod := range ws.Modules {
+			dirs = append(dirs, mod.Dir)
+		}
+		set.WorkReplace = ws.Replace
+		set.Sums = append(set.Sums, filepath.Join(filepath.Dir(ws.File), "go.work.sum"))
+	}
+	var versions = make(map[string]int)
+	for _, dir := range dirs {
+		file := filepath.Join(dir, "go.mod")
+		mod, err := r.readModFile(file)
+		if err != nil {
+			return nil, err
+		}
+		set.Files = append(set.Files, file)
+		set.Sums = append(set.Sums, filepath.Join(dir, "go.sum"))
+		if mod.Module != nil {
+			set.Main = append(set.Main, mod.Module.Mod.Path)
+		}
+		for _, req := range mod.Require {
+			if idx, ok := versions[req.Mod.Path]; ok {
+				if semver.Compare(req.Mod.Version, set.Require[idx].Version) > 0 {
+					set.Require[idx] = req.Mod
+					set.Origin[req.Mod.Path] = mod.Module.Mod.Path
+				}
+				continue
+			}
+			if mod.Module != nil {
+				set.Origin[req.Mod.Path] = mod.Module.Mod.Path
+			}
+			versions[req.Mod.Path] = len(set.Require)
+			set.Require = append(set.Require, req.Mod)
+		}
+		set.Replace = append(set.Replace, absReplaces(mod.Replace, dir)...)
+	}
+	return &set, nil
+}
+
+// Find replacement for module. Workspace replacements override go.mod replacements
+func (set *moduleSet) replacement(mod module.Version) *modfile.Replace {
+	if rep := findReplacement(mod, set.WorkReplace); rep != nil {
+		return rep
 	}
-	mod, err := modfile.Parse(path, data, nil)
+	return findReplacement(mod, set.Replace)
+}
+
+// Module lookup errors which stop import resolution: package is provided by modules, but it can't be used
+func stopsLookup(err error) bool {
+	var ambiguous *ErrAmbiguousImport
+	var mismatch *ErrChecksumMismatch
+	return errors.As(err, &ambiguous) || errors.As(err, &mismatch)
+}
+
+// Find package in modules selected by MVS (see selectVersions) with respect to replacements
+func (r *Resolver) findPackagePathInModules(importPath, modProjectDir string, ws *workspace, trace *Trace) (*importPathInfo, error) {
+	list, err := r.loadBuildList(modProjectDir, ws)
 	if err != nil {
-		return "", err
+		trace.reject(RuleRequire, filepath.Join(modProjectDir, "go.mod"), err)
+		return nil, err
 	}
-	for _, req := range mod.Require {
-		if relatesToPackage(req.Mod.Path, importPath) {
-			ep, err := module.EscapePath(req.Mod.Path)
-			if err != nil {
-				return "", err
+	set := list.moduleSet
+	// several modules could provide the package (ex: cloud.google.com/go and cloud.google.com/go/storage),
+	// so check all of them starting from the longest module path
+	var candidates []module.Version
+	for _, mod := range list.Modules {
+		if relatesToPackage(mod.Path, importPath) {
+			candidates = append(candidates, mod)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return len(candidates[i].Path) > len(candidates[j].Path)
+	})
+	var found []*importPathInfo
+	var foundModules []module.Version
+	var withoutFiles *importPathInfo // the longest module with package directory but without go files (not downloaded yet)
+	var lastErr error
+	for _, mod := range candidates {
+		rule, rep := RuleRequire, set.replacement(mod)
+		if rep != nil {
+			rule = RuleReplace
+		}
+		if r.Fetch {
+			if err := r.fetchRequired(mod, set); err != nil {
+				trace.reject(rule, mod.String(), err)
+				lastErr = err
+				continue
+			}
+		}
+		info, err := r.findPackageInModule(mod, importPath, rep)
+		if err != nil {
+			trace.reject(rule, mod.String(), err)
+			lastErr = err
+			continue
+		}
+		info.Chain = append([]string(nil), list.Chains[mod.Path]...)
+		if !r.hasGoFiles(info.ImportDir) {
+			trace.add(rule, info.ImportDir, false, "module "+mod.String()+" has no go files in package directory")
+			if withoutFiles == nil {
+				withoutFiles = info
+			}
+			continue
+		}
+		if r.Verify {
+			if err := r.verifyRequired(mod, set); err != nil {
+				trace.reject(rule, info.ImportDir, err)
+				return nil, err
 			}
-			if req.Mod.Version != "" {
-				ep = ep + "@" + req.Mod.Version
+		}
+		reason := "module " + mod.String() + " selected by " + strings.Join(info.Chain, " -> ")
+		if rep != nil {
+			reason += ", replaced by " + rep.New.String()
+		}
+		trace.add(rule, info.ImportDir, true, reason)
+		found = append(found, info)
+		foundModules = append(foundModules, mod)
+	}
+	if len(found) > 1 {
+		ambiguous := &ErrAmbiguousImport{ImportPath: importPath}
+		for i, info := range found {
+			ambiguous.Modules = append(ambiguous.Modules, foundModules[i].String())
+			ambiguous.Dirs = append(ambiguous.Dirs, info.ImportDir)
+		}
+		return nil, ambiguous
+	}
+	if len(found) == 1 {
+		return found[0], nil
+	}
+	if withoutFiles != nil {
+		trace.add(RuleRequire, withoutFiles.ImportDir, true, "the longest module path with existing package directory")
+		return withoutFiles, nil
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	// wildcard replacement works even without explicit requirement
+	for _, replaces := range [][]*modfile.Replace{set.WorkReplace, set.Replace} {
+		for _, rep := range replaces {
+			if rep.Old.Version == "" && relatesToPackage(rep.Old.Path, importPath) {
+				info, err := r.findPackageInModule(rep.Old, importPath, rep)
+				if err != nil {
+					trace.reject(RuleReplace, rep.New.String(), err)
+					return nil, err
+				}
+				trace.add(RuleReplace, info.ImportDir, true, "wildcard replacement of "+rep.Old.Path+" without requirement")
+				return info, nil
 			}
-			return filepath.Join(build.Default.GOPATH, "pkg", "mod", ep, tail(req.Mod.Path, importPath)), nil
 		}
 	}
-	// check in root
+	err = fmt.Errorf("no required module provides package %s", importPath)
+	trace.reject(RuleRequire, filepath.Join(modProjectDir, "go.mod"), err)
+	return nil, err
+}
+
+// Fetch required module (or replacement by module version) if it is missing in modules cache
+func (r *Resolver) fetchRequired(mod module.Version, set *moduleSet) error {
+	if rep := set.replacement(mod); rep != nil {
+		if rep.New.Version == "" {
+			return nil
+		}
+		mod = rep.New
+	}
+	return r.fetchModule(mod, set.Sums)
+}
+
+// Verify required module (or replacement by module version) in modules cache by go.sum
+func (r *Resolver) verifyRequired(mod module.Version, set *moduleSet) error {
+	if rep := set.replacement(mod); rep != nil {
+		if rep.New.Version == "" {
+			return nil
+		}
+		mod = rep.New
+	}
+	return r.verifyModule(mod, set.Sums)
+}
+
+// Find package location in module with respect to replacement (could be nil). Filesystem replacement should be absolute
+func (r *Resolver) findPackageInModule(mod module.Version, importPath string, rep *modfile.Replace) (*importPathInfo, error) {
+	childDir := tail(mod.Path, importPath)
+	if rep == nil {
+		root, err := r.moduleCacheDir(mod)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := r.fs().Stat(root); err != nil {
+			return nil, fmt.Errorf("module %s is not in modules cache: %w", mod, err)
+		}
+		info, err := r.inspectDirectory(filepath.Join(root, childDir))
+		if err != nil {
+			return nil, err
+		}
+		info.Version = mod.Version
+		return info, nil
+	}
+	root := rep.New.Path
+	if rep.New.Version != "" {
+		dir, err := r.moduleCacheDir(rep.New)
+		if err != nil {
+			return nil, err
+		}
+		root = dir
+	}
+	info, err := r.inspectDirectory(filepath.Join(root, childDir))
+	if err != nil {
+		return nil, err
+	}
+	// replacement may declare another module path, but package is still imported by original path
+	info.Import = importPath
+	info.Version = mod.Version
+	info.Replaced = true
+	info.Module = &Module{Path: mod.Path, Version: rep.New.Version, Dir: root, Replaced: true}
+	if declared := r.moduleOf(root); declared != nil {
+		info.Module.GoVersion = declared.GoVersion
+	}
+	return info, nil
+}
+
+// Check that directory contains at least one go file
+func (r *Resolver) hasGoFiles(dir string) bool {
+	files, err := r.fs().ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, file := range files {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), ".go") {
+			return true
+		}
+	}
+	return false
+}
+
+// Find replace directive for module. Version-specific replacement has priority over wildcard (versionless) replacement
+func findReplacement(mod module.Version, replaces []*modfile.Replace) *modfile.Replace {
+	var wildcard *modfile.Replace
+	for _, rep := range replaces {
+		if rep.Old.Path != mod.Path {
+			continue
+		}
+		if rep.Old.Version == mod.Version {
+			return rep
+		}
+		if rep.Old.Version == "" {
+			wildcard = rep
+		}
+	}
+	return wildcard
+}
 
-	return "", errors.New("not found in go.mod")
+// Location of module sources in modules cache
+func (r *Resolver) moduleCacheDir(mod module.Version) (string, error) {
+	ep, err := module.EscapePath(mod.Path)
+	if err != nil {
+		return "", err
+	}
+	if mod.Version != "" {
+		ev, err := module.EscapeVersion(mod.Version)
+		if err != nil {
+			return "", err
+		}
+		ep = ep + "@" + ev
+	}
+	return filepath.Join(r.ModCache, ep), nil
 }
 
 // github.com/reddec/godetector/cmd
@@ -1,12 +1,16 @@
 package godetector
 
 import (
+	"errors"
 	"github.com/stretchr/testify/assert"
 	"go/build"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
+	"testing/fstest"
 )
 
 func TestFindPackageDefinitionDir(t *testing.T) {
@@ -73,3 +77,239 @@ func TestFindPackageDefinitionDir(t *testing.T) {
 	}
 	assert.Equal(t, "time", timePkg.ToImport().Package)
 }
+
+func TestInspectImport_replace(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "godetector")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(tmp)
+	writeFile(t, filepath.Join(tmp, "main", "go.mod"), `module example.com/main
+
+require (
+	example.com/lib v1.0.0
+	example.com/xmod v1.0.0
+)
+
+replace example.com/lib => ../lib
+
+replace example.com/xmod v1.0.0 => golang.org/x/mod v0.12.0
+`)
+	writeFile(t, filepath.Join(tmp, "lib", "go.mod"), "module example.com/lib\n")
+	writeFile(t, filepath.Join(tmp, "lib", "sub", "sub.go"), "package sub\n")
+	writeFile(t, filepath.Join(tmp, "modcache", "golang.org", "x", "mod@v0.12.0", "go.mod"), "module golang.org/x/mod\n")
+	writeFile(t, filepath.Join(tmp, "modcache", "golang.org", "x", "mod@v0.12.0", "modfile", "read.go"), "package modfile\n")
+
+	r := &Resolver{ModCache: filepath.Join(tmp, "modcache"), Work: "off"}
+
+	info, err := r.InspectImport("example.com/lib/sub", filepath.Join(tmp, "main"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	imp := info.ToImport()
+	assert.Equal(t, "example.com/lib/sub", imp.Path)
+	assert.Equal(t, "sub", imp.Package)
+	assert.Equal(t, filepath.Join(tmp, "lib", "sub"), imp.Location)
+	assert.Equal(t, filepath.Join(tmp, "lib"), imp.RootPackageLocation)
+	assert.True(t, imp.Replaced)
+
+	info, err = r.InspectImport("example.com/xmod/modfile", filepath.Join(tmp, "main"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	imp = info.ToImport()
+	assert.Equal(t, "example.com/xmod/modfile", imp.Path)
+	assert.Equal(t, "modfile", imp.Package)
+	assert.Equal(t, filepath.Join(tmp, "modcache", "golang.org", "x", "mod@v0.12.0", "modfile"), imp.Location)
+	assert.Equal(t, GoCache, imp.Type)
+	assert.True(t, imp.Replaced)
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInspectImport_workspace(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "godetector")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(tmp)
+	defer os.Setenv("GOWORK", os.Getenv("GOWORK"))
+	os.Setenv("GOWORK", "")
+
+	writeFile(t, filepath.Join(tmp, "go.work"), `go 1.18
+
+use (
+	./alfa
+	./beta
+)
+
+replace example.com/lib => ./lib
+`)
+	writeFile(t, filepath.Join(tmp, "alfa", "go.mod"), "module example.com/alfa\n\nrequire example.com/lib v1.0.0\n")
+	writeFile(t, filepath.Join(tmp, "beta", "go.mod"), "module example.com/beta\n")
+	writeFile(t, filepath.Join(tmp, "beta", "gamma", "gamma.go"), "package gamma\n")
+	writeFile(t, filepath.Join(tmp, "lib", "go.mod"), "module example.com/lib\n")
+	writeFile(t, filepath.Join(tmp, "lib", "lib.go"), "package lib\n")
+
+	r := NewResolver()
+	info, err := r.InspectImport("example.com/beta/gamma", filepath.Join(tmp, "alfa"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	imp := info.ToImport()
+	assert.Equal(t, GoWork, imp.Type)
+	assert.Equal(t, "gamma", imp.Package)
+	assert.Equal(t, filepath.Join(tmp, "beta", "gamma"), imp.Location)
+	assert.Equal(t, filepath.Join(tmp, "beta"), imp.RootPackageLocation)
+	if assert.NotNil(t, imp.Module) {
+		assert.True(t, imp.Module.Workspace)
+		assert.Equal(t, "example.com/beta", imp.Module.Path)
+	}
+	root, err := r.InspectImportByDir(filepath.Join(tmp, "alfa"))
+	if assert.NoError(t, err) && assert.NotNil(t, root.Module) {
+		assert.True(t, root.Module.Main)
+		assert.True(t, root.Module.Workspace)
+	}
+
+	info, err = r.InspectImport("example.com/lib", filepath.Join(tmp, "alfa"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, filepath.Join(tmp, "lib"), info.ImportDir)
+	assert.True(t, info.Replaced)
+
+	os.Setenv("GOWORK", "off")
+	r = NewResolver()
+	_, err = r.InspectImport("example.com/beta/gamma", filepath.Join(tmp, "alfa"))
+	assert.Error(t, err)
+}
+
+func TestInspectImport_vendor(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "godetector")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(tmp)
+	defer os.Setenv("GOFLAGS", os.Getenv("GOFLAGS"))
+	os.Setenv("GOFLAGS", "")
+
+	writeFile(t, filepath.Join(tmp, "go.mod"), "module example.com/main\n\ngo 1.14\n\nrequire example.com/lib v1.2.3\n")
+	writeFile(t, filepath.Join(tmp, "vendor", "modules.txt"), `# example.com/lib v1.2.3
+## explicit
+example.com/lib/sub
+`)
+	writeFile(t, filepath.Join(tmp, "vendor", "example.com", "lib", "sub", "sub.go"), "package sub\n")
+
+	r := NewResolver()
+	info, err := r.InspectImport("example.com/lib/sub", tmp)
+	if !assert.NoError(t, err) {
+		return
+	}
+	imp := info.ToImport()
+	assert.Equal(t, InLocalVendor, imp.Type)
+	assert.Equal(t, "sub", imp.Package)
+	assert.Equal(t, "v1.2.3", imp.Version)
+	assert.Equal(t, filepath.Join(tmp, "vendor", "example.com", "lib", "sub"), imp.Location)
+	assert.Equal(t, filepath.Join(tmp, "vendor", "example.com", "lib"), imp.RootPackageLocation)
+
+	os.Setenv("GOFLAGS", "-mod=mod")
+	r = NewResolver()
+	info, err = r.InspectImport("example.com/lib/sub", tmp)
+	if err == nil {
+		assert.NotEqual(t, InLocalVendor, info.LocationType)
+	}
+}
+
+func TestInspectImport_nestedModule(t *testing.T) {
+	r := &Resolver{
+		ModCache: "/modcache",
+		Work:     "off",
+		FS: NewFS(fstest.MapFS{
+			"repo/go.mod":             {Data: []byte("module example.com/repo\n\nrequire example.com/repo/tools v1.0.0\n")},
+			"repo/pkg/pkg.go":         {Data: []byte("package pkg\n")},
+			"repo/tools/go.mod":       {Data: []byte("module example.com/repo/tools\n")},
+			"repo/tools/gen/gen.go":   {Data: []byte("package gen\n")},
+			"repo/local/go.mod":       {Data: []byte("module example.com/repo/local\n")},
+			"repo/local/util/util.go": {Data: []byte("package util\n")},
+			"modcache/example.com/repo/tools@v1.0.0/gen/g.go": {Data: []byte("package gen\n")},
+		}),
+	}
+
+	info, err := r.InspectImport("example.com/repo/pkg", "/repo")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "/repo/pkg", info.ImportDir)
+		assert.Equal(t, "/repo", info.PackageRootDir)
+	}
+
+	// required nested module resolved through modules cache
+	info, err = r.InspectImport("example.com/repo/tools/gen", "/repo")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "/modcache/example.com/repo/tools@v1.0.0/gen", info.ImportDir)
+		assert.Equal(t, GoCache, info.LocationType)
+	}
+
+	// not required nested module resolved locally with own root
+	info, err = r.InspectImport("example.com/repo/local/util", "/repo")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "/repo/local/util", info.ImportDir)
+		assert.Equal(t, "/repo/local", info.PackageRootDir)
+		assert.Equal(t, "example.com/repo/local/util", info.Import)
+	}
+
+	dirInfo, err := r.InspectDirectory("/repo/tools/gen")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "/repo/tools", dirInfo.PackageRootDir)
+		assert.Equal(t, "example.com/repo/tools/gen", dirInfo.Import)
+	}
+}
+
+func TestInspectImport_noGoRoot(t *testing.T) {
+	r := &Resolver{
+		Work: "off",
+		FS: NewFS(fstest.MapFS{
+			"project/go.mod":     {Data: []byte("module example.com/project\n")},
+			"project/pkg/pkg.go": {Data: []byte("package pkg\n")},
+		}),
+	}
+	_, err := r.InspectImport("fmt", "/project")
+	var notFound *ErrNotFound
+	if assert.True(t, errors.As(err, &notFound)) {
+		for _, location := range notFound.Locations {
+			assert.False(t, strings.HasPrefix(location, "GOROOT"), "GOROOT is not set: %s", location)
+		}
+	}
+	info, err := r.InspectDirectory("/project/pkg")
+	if assert.NoError(t, err) {
+		assert.Equal(t, GoMod, info.LocationType)
+	}
+	std, err := r.StdPackages()
+	assert.NoError(t, err)
+	assert.Empty(t, std)
+}
+
+func TestInspectImport_nestedModuleMismatch(t *testing.T) {
+	r := &Resolver{
+		ModCache: "/modcache",
+		Work:     "off",
+		Verify:   true,
+		FS: NewFS(fstest.MapFS{
+			"repo/go.mod":           {Data: []byte("module example.com/repo\n\nrequire example.com/repo/tools v1.0.0\n")},
+			"repo/go.sum":           {Data: []byte("example.com/repo/tools v1.0.0 h1:AAAA=\n")},
+			"repo/tools/go.mod":     {Data: []byte("module example.com/repo/tools\n")},
+			"repo/tools/gen/gen.go": {Data: []byte("package gen\n")},
+			"modcache/example.com/repo/tools@v1.0.0/gen/g.go": {Data: []byte("package gen\n")},
+		}),
+	}
+	_, err := r.InspectImport("example.com/repo/tools/gen", "/repo")
+	var mismatch *ErrChecksumMismatch
+	if assert.True(t, errors.As(err, &mismatch), "local checkout should not hide checksum mismatch: %v", err) {
+		assert.Equal(t, "example.com/repo/tools", mismatch.Module)
+	}
+}
@@ -0,0 +1,97 @@
+package godetector
+
+import (
+	"golang.org/x/mod/module"
+	"path/filepath"
+	"strings"
+)
+
+// Module which owns package directory
+type Module struct {
+	Path      string // module path: example.com/project
+	Version   string // module version. Empty for main, workspace and local (replaced by directory) modules
+	Dir       string // root directory of module
+	Main      bool   // module is developed locally: module of working directory or workspace module
+	Replaced  bool   // location defined by replace directive
+	Vendored  bool   // module copied to vendor directory
+	Workspace bool   // module is used by go.work workspace
+	GoVersion string // go directive of go.mod
+}
+
+func (m *Module) copy() *Module {
+	if m == nil {
+		return nil
+	}
+	cp := *m
+	return &cp
+}
+
+// Module declared by go.mod in directory. Returns nil if directory has no go.mod
+func (r *Resolver) moduleOf(dir string) *Module {
+	file, err := r.readModFile(filepath.Join(dir, "go.mod"))
+	if err != nil || file.Module == nil {
+		return nil
+	}
+	mod := &Module{Path: file.Module.Mod.Path, Dir: dir}
+	if file.Go != nil {
+		mod.GoVersion = file.Go.Version
+	}
+	return mod
+}
+
+// Module of directory in modules cache: module root is the first directory <escaped path>@<escaped version>.
+// Upper-case letters in path and version are escaped by '!' (see module.EscapePath)
+func (r *Resolver) cacheModule(dir string) (*Module, error) {
+	rel, err := filepath.Rel(r.ModCache, dir)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	if parts[0] == "cache" {
+		return nil, &ErrNoModule{Dir: dir, Reason: "directory is in download cache of modules"}
+	}
+	for i, part := range parts {
+		at := strings.Index(part, "@")
+		if at < 0 {
+			continue
+		}
+		escaped := strings.Join(append(parts[:i:i], part[:at]), "/")
+		path, err := module.UnescapePath(escaped)
+		if err != nil {
+			return nil, &ErrNoModule{Dir: dir, Reason: "invalid module directory in modules cache: " + err.Error()}
+		}
+		version, err := module.UnescapeVersion(part[at+1:])
+		if err != nil {
+			return nil, &ErrNoModule{Dir: dir, Reason: "invalid module directory in modules cache: " + err.Error()}
+		}
+		mod := &Module{
+			Path:    path,
+			Version: version,
+			Dir:     filepath.Join(r.ModCache, filepath.FromSlash(strings.Join(parts[:i+1], "/"))),
+		}
+		if declared := r.moduleOf(mod.Dir); declared != nil {
+			mod.GoVersion = declared.GoVersion
+		}
+		return mod, nil
+	}
+	return nil, &ErrNoModule{Dir: dir, Reason: "directory is in modules cache, but not in module directory <module>@<version>"}
+}
+
+// Copy module with workspace flag if module directory is used by workspace of directory
+func (r *Resolver) markWorkspace(mod *Module, dir string) *Module {
+	if mod == nil {
+		return nil
+	}
+	ws, err := r.findWorkspace(dir)
+	if err != nil || ws == nil {
+		return mod
+	}
+	for _, used := range ws.Modules {
+		if used.Dir == mod.Dir {
+			cp := *mod
+			cp.Workspace = true
+			return &cp
+		}
+	}
+	return mod
+}
@@ -0,0 +1,99 @@
+package godetector
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"testing/fstest"
+)
+
+func TestResolver_Module(t *testing.T) {
+	r := &Resolver{
+		GOROOT:   "/goroot",
+		GOPATH:   []string{"/gopath"},
+		ModCache: "/gopath/pkg/mod",
+		Work:     "off",
+		FS: NewFS(fstest.MapFS{
+			"goroot/src/go.mod":       {Data: []byte("module std\n\ngo 1.21\n")},
+			"goroot/src/fmt/print.go": {Data: []byte("package fmt\n")},
+			"gopath/pkg/mod/github.com/!burnt!sushi/toml@v1.2.0/go.mod":  {Data: []byte("module github.com/BurntSushi/toml\n\ngo 1.16\n")},
+			"gopath/pkg/mod/github.com/!burnt!sushi/toml@v1.2.0/toml.go": {Data: []byte("package toml\n")},
+			"project/go.mod":              {Data: []byte("module example.com/project\n\ngo 1.20\n\nrequire (\n\tgithub.com/BurntSushi/toml v1.2.0\n\texample.com/lib v1.0.0\n)\n\nreplace example.com/lib => ../lib\n")},
+			"project/alfa/alfa.go":        {Data: []byte("package alfa\n")},
+			"lib/go.mod":                  {Data: []byte("module example.com/lib\n\ngo 1.19\n")},
+			"lib/lib.go":                  {Data: []byte("package lib\n")},
+			"vendored/go.mod":             {Data: []byte("module example.com/vendored\n\ngo 1.17\n\nrequire example.com/dep v1.0.0\n")},
+			"vendored/vendor/modules.txt": {Data: []byte("# example.com/dep v1.0.0\n## explicit; go 1.18\nexample.com/dep/sub\n")},
+			"vendored/vendor/example.com/dep/sub/sub.go": {Data: []byte("package sub\n")},
+		}),
+	}
+
+	imp, err := r.InspectImportByDir("/project/alfa")
+	if assert.NoError(t, err) && assert.NotNil(t, imp.Module) {
+		assert.Equal(t, Module{Path: "example.com/project", Dir: "/project", Main: true, GoVersion: "1.20"}, *imp.Module)
+	}
+
+	imp, err = r.InspectImportByDir("/gopath/pkg/mod/github.com/!burnt!sushi/toml@v1.2.0")
+	if assert.NoError(t, err) && assert.NotNil(t, imp.Module) {
+		assert.Equal(t, "github.com/BurntSushi/toml", imp.Module.Path)
+		assert.Equal(t, "v1.2.0", imp.Module.Version)
+		assert.Equal(t, "1.16", imp.Module.GoVersion)
+		assert.False(t, imp.Module.Main)
+	}
+
+	info, err := r.InspectImport("example.com/lib", "/project/alfa")
+	if assert.NoError(t, err) && assert.NotNil(t, info.Module) {
+		assert.Equal(t, Module{Path: "example.com/lib", Dir: "/lib", Replaced: true, GoVersion: "1.19"}, *info.Module)
+	}
+
+	info, err = r.InspectImport("example.com/dep/sub", "/vendored")
+	if assert.NoError(t, err) && assert.NotNil(t, info.Module) {
+		assert.Equal(t, Module{Path: "example.com/dep", Version: "v1.0.0", Dir: "/vendored/vendor/example.com/dep", Vendored: true, GoVersion: "1.18"}, *info.Module)
+	}
+
+	// reverse lookup of vendored directory
+	vendored := Module{Path: "example.com/dep", Version: "v1.0.0", Dir: "/vendored/vendor/example.com/dep", Vendored: true, GoVersion: "1.18"}
+	imp, err = r.InspectImportByDir("/vendored/vendor/example.com/dep/sub")
+	if assert.NoError(t, err) && assert.NotNil(t, imp.Module) {
+		assert.Equal(t, "example.com/dep/sub", imp.Path)
+		assert.Equal(t, InLocalVendor, imp.Type)
+		assert.Equal(t, "v1.0.0", imp.Version)
+		assert.Equal(t, vendored, *imp.Module)
+	}
+	imp, err = r.InspectImportByDir("/vendored/vendor/example.com/dep")
+	if assert.NoError(t, err) && assert.NotNil(t, imp.Module) {
+		assert.Equal(t, "example.com/dep", imp.Path)
+		assert.Equal(t, vendored, *imp.Module)
+	}
+
+	info, err = r.InspectImport("fmt", "/project")
+	if assert.NoError(t, err) && assert.NotNil(t, info.Module) {
+		assert.Equal(t, "std", info.Module.Path)
+	}
+}
+
+func TestResolver_isUnderModCache(t *testing.T) {
+	r := &Resolver{
+		GOROOT:   "/goroot",
+		ModCache: "/gopath/pkg/mod",
+		Work:     "off",
+		FS: NewFS(fstest.MapFS{
+			"gopath/pkg/mod/github.com/!burnt!sushi/toml@v1.2.0-!r!c1/internal/x.go": {Data: []byte("package internal\n")},
+			"gopath/pkg/mod/cache/download/example.com/dep/@v/v1.0.0.mod":            {Data: []byte("module example.com/dep\n")},
+			"gopath/pkg/mod/example.com/dep@v1.0.0/sub@dir/sub.go":                   {Data: []byte("package sub\n")},
+		}),
+	}
+	info, err := r.InspectDirectory("/gopath/pkg/mod/github.com/!burnt!sushi/toml@v1.2.0-!r!c1/internal")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "github.com/BurntSushi/toml/internal", info.Import)
+		assert.Equal(t, "/gopath/pkg/mod/github.com/!burnt!sushi/toml@v1.2.0-!r!c1", info.PackageRootDir)
+		assert.Equal(t, "v1.2.0-RC1", info.Module.Version)
+	}
+	info, err = r.InspectDirectory("/gopath/pkg/mod/example.com/dep@v1.0.0/sub@dir")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "example.com/dep/sub@dir", info.Import)
+	}
+	_, err = r.InspectDirectory("/gopath/pkg/mod/cache/download/example.com/dep/@v")
+	assert.Error(t, err)
+	_, err = r.InspectDirectory("/gopath/pkg/mod/github.com/!burnt!sushi")
+	assert.Error(t, err)
+}
@@ -0,0 +1,165 @@
+package godetector
+
+import (
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+	"path/filepath"
+)
+
+// Module versions selected by minimal version selection (MVS) over requirement graph of main modules
+type buildList struct {
+	*moduleSet
+	Modules []module.Version    // selected versions in order of discovery
+	Chains  map[string][]string // requirement chain of selected version by module path: main module, intermediate modules, selected module
+}
+
+// Load requirements of main module (or workspace) and select versions with cache. Result depends on every go.mod
+// file in the graph
+func (r *Resolver) loadBuildList(modProjectDir string, ws *workspace) (*buildList, error) {
+	key := cacheKey{kind: cacheBuildList, path: modProjectDir}
+	if ws != nil {
+		key.path = ws.File
+	}
+	value, err := r.cache().get(r.fs(), key, func() (interface{}, []string, error) {
+		set, err := r.loadModuleSet(modProjectDir, ws)
+		if err != nil {
+			return nil, []string{filepath.Join(modProjectDir, "go.mod")}, err
+		}
+		list, deps := r.selectVersions(set)
+		return list, append(deps, set.Files...), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*buildList), nil
+}
+
+// Select versions the same way as go command does: the highest version of each module required anywhere in the
+// graph. Go.mod files of dependencies are taken from replacements, module cache download directory
+// (cache/download/<module>/@v/<version>.mod) or extracted modules without network access; requirements
+// of modules without available go.mod are not traversed. Returns list and go.mod files used for selection.
+func (r *Resolver) selectVersions(set *moduleSet) (*buildList, []string) {
+	type node struct {
+		mod    module.Version
+		parent *node
+	}
+	var mainModules = make(map[string]bool)
+	for _, path := range set.Main {
+		mainModules[path] = true
+	}
+	var queue []*node
+	for _, req := range set.Require {
+		queue = append(queue, &node{mod: req})
+	}
+	var selected = make(map[string]*node)
+	var visited = make(map[module.Version]bool)
+	var order []string
+	var files []string
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if mainModules[n.mod.Path] || visited[n.mod] {
+			continue
+		}
+		visited[n.mod] = true
+		if current, ok := selected[n.mod.Path]; !ok {
+			order = append(order, n.mod.Path)
+			selected[n.mod.Path] = n
+		} else if semver.Compare(n.mod.Version, current.mod.Version) > 0 {
+			selected[n.mod.Path] = n
+		}
+		mod, file, err := r.dependencyModFile(n.mod, set)
+		if file != "" {
+			files = append(files, file)
+		}
+		if err != nil {
+			continue
+		}
+		for _, req := range mod.Require {
+			queue = append(queue, &node{mod: req.Mod, parent: n})
+		}
+	}
+
+	list := &buildList{
+		moduleSet: set,
+		Chains:    make(map[string][]string, len(order)),
+	}
+	for _, path := range order {
+		n := selected[path]
+		list.Modules = append(list.Modules, n.mod)
+		var chain []string
+		for p := n; p != nil; p = p.parent {
+			chain = append([]string{p.mod.String()}, chain...)
+			if p.parent == nil {
+				chain = append([]string{set.Origin[p.mod.Path]}, chain...)
+			}
+		}
+		list.Chains[path] = chain
+	}
+	return list, files
+}
+
+// Read go.mod of dependency with respect to replacements. Returns parsed file and location of go.mod
+func (r *Resolver) dependencyModFile(mod module.Version, set *moduleSet) (*modfile.File, string, error) {
+	var file string
+	if rep := set.replacement(mod); rep != nil {
+		if rep.New.Version == "" {
+			file = filepath.Join(rep.New.Path, "go.mod")
+		} else {
+			mod = rep.New
+		}
+	}
+	if file == "" {
+		download, err := r.downloadCacheFile(mod, ".mod")
+		if err != nil {
+			return nil, "", err
+		}
+		file = download
+		if _, err := r.fs().Stat(file); err != nil {
+			// module could be extracted without download cache
+			dir, err := r.moduleCacheDir(mod)
+			if err != nil {
+				return nil, "", err
+			}
+			file = filepath.Join(dir, "go.mod")
+			if _, err := r.fs().Stat(file); err != nil && r.Fetch && r.fetchModFile(mod, set.Sums) == nil {
+				file = download
+			}
+		}
+	}
+	parsed, err := r.readDepModFile(file)
+	return parsed, file, err
+}
+
+// Location of file with extension (.info, .mod, .zip) for module version in download directory of modules cache
+func (r *Resolver) downloadCacheFile(mod module.Version, ext string) (string, error) {
+	ep, err := module.EscapePath(mod.Path)
+	if err != nil {
+		return "", err
+	}
+	ev, err := module.EscapeVersion(mod.Version)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(r.ModCache, "cache", "download", filepath.FromSlash(ep), "@v", ev+ext), nil
+}
+
+// Read and leniently parse go.mod file of dependency with cache. Returned file should not be modified
+func (r *Resolver) readDepModFile(path string) (*modfile.File, error) {
+	value, err := r.cache().get(r.fs(), cacheKey{kind: cacheDepModFile, path: path}, func() (interface{}, []string, error) {
+		data, err := r.fs().ReadFile(path)
+		if err != nil {
+			return nil, []string{path}, err
+		}
+		mod, err := modfile.ParseLax(path, data, nil)
+		if err != nil {
+			return nil, []string{path}, badGoMod(path, err)
+		}
+		return mod, []string{path}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*modfile.File), nil
+}
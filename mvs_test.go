@@ -0,0 +1,89 @@
+package godetector
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"testing/fstest"
+)
+
+func TestResolver_selectVersions(t *testing.T) {
+	const download = "modcache/cache/download/example.com/"
+	r := &Resolver{
+		ModCache: "/modcache",
+		Work:     "off",
+		FS: NewFS(fstest.MapFS{
+			"project/go.mod": {Data: []byte(`module example.com/project
+
+go 1.16
+
+require (
+	example.com/a v1.0.0
+	example.com/b v1.0.0
+)
+`)},
+			download + "a/@v/v1.0.0.mod":                {Data: []byte("module example.com/a\n\nrequire example.com/c v1.1.0\n")},
+			download + "a/@v/v1.1.0.mod":                {Data: []byte("module example.com/a\n\nrequire example.com/c v1.0.0\n")},
+			download + "b/@v/v1.0.0.mod":                {Data: []byte("module example.com/b\n\nrequire (\n\texample.com/a v1.1.0\n\texample.com/c v1.2.0\n)\n")},
+			download + "c/@v/v1.2.0.mod":                {Data: []byte("module example.com/c\n\nrequire example.com/project v0.1.0\n")},
+			"modcache/example.com/a@v1.1.0/a.go":        {Data: []byte("package a\n")},
+			"modcache/example.com/c@v1.1.0/pkg/pkg.go":  {Data: []byte("package pkg\n")},
+			"modcache/example.com/c@v1.2.0/pkg/pkg.go":  {Data: []byte("package pkg\n")},
+			"modcache/example.com/c@v1.2.0/pkg/more.go": {Data: []byte("package pkg\n")},
+		}),
+	}
+
+	info, err := r.InspectImport("example.com/c/pkg", "/project")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "/modcache/example.com/c@v1.2.0/pkg", info.ImportDir)
+		assert.Equal(t, "v1.2.0", info.Version)
+		assert.Equal(t, []string{"example.com/project", "example.com/b@v1.0.0", "example.com/c@v1.2.0"}, info.Chain)
+	}
+
+	info, err = r.InspectImport("example.com/a", "/project")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "/modcache/example.com/a@v1.1.0", info.ImportDir)
+		assert.Equal(t, []string{"example.com/project", "example.com/b@v1.0.0", "example.com/a@v1.1.0"}, info.Chain)
+	}
+}
+
+func TestResolver_longestModulePath(t *testing.T) {
+	r := &Resolver{
+		ModCache: "/modcache",
+		Work:     "off",
+		FS: NewFS(fstest.MapFS{
+			"project/go.mod": {Data: []byte(`module example.com/project
+
+require (
+	cloud.google.com/go v0.1.0
+	cloud.google.com/go/storage v1.0.0
+)
+`)},
+			"modcache/cloud.google.com/go@v0.1.0/go.mod":                   {Data: []byte("module cloud.google.com/go\n")},
+			"modcache/cloud.google.com/go@v0.1.0/storage/storage.go":       {Data: []byte("package storage\n")},
+			"modcache/cloud.google.com/go@v0.1.0/storage/legacy/legacy.go": {Data: []byte("package legacy\n")},
+			"modcache/cloud.google.com/go@v0.1.0/storage/internal/README":  {Data: []byte("moved\n")},
+			"modcache/cloud.google.com/go/storage@v1.0.0/go.mod":           {Data: []byte("module cloud.google.com/go/storage\n")},
+			"modcache/cloud.google.com/go/storage@v1.0.0/storage.go":       {Data: []byte("package storage\n")},
+			"modcache/cloud.google.com/go/storage@v1.0.0/internal/x.go":    {Data: []byte("package internal\n")},
+		}),
+	}
+
+	info, err := r.InspectImport("cloud.google.com/go/storage/internal", "/project")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "/modcache/cloud.google.com/go/storage@v1.0.0/internal", info.ImportDir)
+		assert.Equal(t, "v1.0.0", info.Version)
+	}
+
+	info, err = r.InspectImport("cloud.google.com/go/storage/legacy", "/project")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "/modcache/cloud.google.com/go@v0.1.0/storage/legacy", info.ImportDir)
+		assert.Equal(t, "v0.1.0", info.Version)
+	}
+
+	_, err = r.InspectImport("cloud.google.com/go/storage", "/project")
+	var ambiguous *ErrAmbiguousImport
+	if assert.True(t, errors.As(err, &ambiguous)) {
+		assert.Equal(t, []string{"cloud.google.com/go/storage@v1.0.0", "cloud.google.com/go@v0.1.0"}, ambiguous.Modules)
+	}
+}
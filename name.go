@@ -3,34 +3,149 @@ package godetector
 import (
 	"go/parser"
 	"go/token"
-	"io/ioutil"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 // Find package name by directory: scans go file to detect package definition and uses path detection as fail-over
 func FindPackageNameByDir(dir string) string {
+	return DefaultResolver().FindPackageNameByDir(dir)
+}
+
+// Find package name by directory using resolver filesystem and build context
+func (r *Resolver) FindPackageNameByDir(dir string) string {
+	name, _ := r.InspectPackageName(dir)
+	return name.Name
+}
+
+// How package name was determined
+type NameSource int
+
+const (
+	NameFromClause     NameSource = 0 // parsed from package clause of go files
+	NameFromImportPath NameSource = 1 // guessed by last element of import path
+	NameFromDir        NameSource = 2 // guessed by directory name
+)
+
+// Package name and the way it was determined
+type PackageName struct {
+	Name   string
+	Source NameSource
+}
+
+// Inspect package name by directory (see Resolver.InspectPackageName)
+func InspectPackageName(dir string) (*PackageName, error) {
+	return DefaultResolver().InspectPackageName(dir)
+}
+
+// Inspect package name by directory. Name is always returned: if it can't be parsed from go files, it will be guessed
+// by import path of directory (see ImportPathToAssumedName) or by directory name, and the error (ErrUnreadableDir,
+// ErrNoGoFiles, ErrConflictingPackages) explains why package clause was not used.
+func (r *Resolver) InspectPackageName(dir string) (*PackageName, error) {
 	abs, err := filepath.Abs(dir)
 	if err != nil {
 		abs = dir
 	}
-	guess := filepath.Base(abs)
-	files, err := ioutil.ReadDir(abs)
+	name, err := r.DetectPackageName(abs)
+	if err == nil {
+		return &PackageName{Name: name, Source: NameFromClause}, nil
+	}
+	if info, infoErr := r.inspectDirectory(abs); infoErr == nil && info.Import != "" {
+		return &PackageName{Name: ImportPathToAssumedName(info.Import), Source: NameFromImportPath}, err
+	}
+	return &PackageName{Name: filepath.Base(abs), Source: NameFromDir}, err
+}
+
+// Detect package name by go files in directory (see Resolver.DetectPackageName)
+func DetectPackageName(dir string) (string, error) {
+	return DefaultResolver().DetectPackageName(dir)
+}
+
+// Detect package name by go files in directory. Files excluded by build constraints of resolver build context
+// (//go:build and // +build lines including 'ignore', _GOOS_GOARCH.go suffixes) and test files are skipped.
+// Returns ErrConflictingPackages if remaining files define different packages or ErrNoGoFiles if there are no such files.
+func (r *Resolver) DetectPackageName(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
 	if err != nil {
-		return guess
+		return "", err
 	}
+	return r.detectPackageNameCached(abs)
+}
+
+func (r *Resolver) detectPackageName(abs string) (string, error) {
+	files, err := r.fs().ReadDir(abs)
+	if err != nil {
+		return "", &ErrUnreadableDir{Dir: abs, Err: err}
+	}
+	ctx := r.buildContext()
+	var name, nameFile string
 	for _, file := range files {
-		if file.IsDir() {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".go") || strings.HasSuffix(file.Name(), "_test.go") {
+			continue
+		}
+		if ok, err := ctx.MatchFile(abs, file.Name()); err != nil || !ok {
+			continue
+		}
+		var fs token.FileSet
+		parsed, err := r.ParseFile(&fs, filepath.Join(abs, file.Name()), parser.PackageClauseOnly)
+		if err != nil {
 			continue
 		}
-		if strings.HasSuffix(file.Name(), ".go") && !strings.HasSuffix(file.Name(), "_test.go") {
-			var fs token.FileSet
-			parsed, err := parser.ParseFile(&fs, filepath.Join(abs, file.Name()), nil, parser.PackageClauseOnly)
-			if err != nil {
-				continue
+		if name == "" {
+			name = parsed.Name.Name
+			nameFile = file.Name()
+		} else if name != parsed.Name.Name {
+			return "", &ErrConflictingPackages{
+				Dir:   abs,
+				Names: []string{name, parsed.Name.Name},
+				Files: []string{nameFile, file.Name()},
 			}
-			return parsed.Name.Name
 		}
 	}
-	return guess
+	if name == "" {
+		return "", &ErrNoGoFiles{Dir: abs}
+	}
+	return name, nil
+}
+
+// Guess package name by import path the same way as go toolchain (goimports) does: last element of import path
+// without major version suffix (example.com/foo/v2), 'go-' prefix and everything after first non-identifier
+// character (gopkg.in/yaml.v3, example.com/foo-go).
+func ImportPathToAssumedName(importPath string) string {
+	base := path.Base(importPath)
+	if strings.HasPrefix(base, "v") {
+		if _, err := strconv.Atoi(base[1:]); err == nil {
+			dir := path.Dir(importPath)
+			if dir != "." {
+				base = path.Base(dir)
+			}
+		}
+	}
+	base = strings.TrimPrefix(base, "go-")
+	if i := strings.IndexFunc(base, notIdentifier); i >= 0 {
+		base = base[:i]
+	}
+	return base
+}
+
+func notIdentifier(ch rune) bool {
+	return !('a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' ||
+		'0' <= ch && ch <= '9' ||
+		ch == '_' ||
+		ch >= utf8.RuneSelf && (unicode.IsLetter(ch) || unicode.IsDigit(ch)))
+}
+
+// Package name by go files in directory or by import path if it can't be detected
+func (r *Resolver) packageName(dir, importPath string) string {
+	if name, err := r.DetectPackageName(dir); err == nil {
+		return name
+	}
+	if importPath != "" {
+		return ImportPathToAssumedName(importPath)
+	}
+	return filepath.Base(dir)
 }
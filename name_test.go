@@ -0,0 +1,120 @@
+package godetector
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestResolver_DetectPackageName(t *testing.T) {
+	ctx := build.Default
+	ctx.GOOS = "linux"
+	ctx.GOARCH = "amd64"
+	r := &Resolver{
+		Context: ctx,
+		FS: NewFS(fstest.MapFS{
+			"alfa/a_gen.go":     {Data: []byte("//go:build ignore\n\npackage main\n")},
+			"alfa/alfa.go":      {Data: []byte("package alfa\n")},
+			"alfa/alfa_test.go": {Data: []byte("package alfa_test\n")},
+			"alfa/b_old.go":     {Data: []byte("// +build ignore\n\npackage main\n")},
+			"alfa/c_windows.go": {Data: []byte("package windows\n")},
+			"alfa/d_darwin.go":  {Data: []byte("//go:build darwin\n\npackage darwin\n")},
+			"beta/a.go":         {Data: []byte("package alfa\n")},
+			"beta/b.go":         {Data: []byte("package beta\n")},
+			"gamma/gamma.txt":   {Data: []byte("package gamma\n")},
+		}),
+	}
+
+	name, err := r.DetectPackageName("/alfa")
+	assert.NoError(t, err)
+	assert.Equal(t, "alfa", name)
+	assert.Equal(t, "alfa", r.FindPackageNameByDir("/alfa"))
+
+	_, err = r.DetectPackageName("/beta")
+	assert.Error(t, err)
+
+	_, err = r.DetectPackageName("/gamma")
+	assert.Error(t, err)
+	assert.Equal(t, "gamma", r.FindPackageNameByDir("/gamma"))
+}
+
+func TestResolver_InspectPackageName(t *testing.T) {
+	r := &Resolver{
+		Work: "off",
+		FS: NewFS(fstest.MapFS{
+			"project/go.mod":          {Data: []byte("module example.com/project/v2\n")},
+			"project/alfa/alfa.go":    {Data: []byte("package alfa\n")},
+			"project/beta/a.go":       {Data: []byte("package alfa\n")},
+			"project/beta/b.go":       {Data: []byte("package beta\n")},
+			"project/gamma/README":    {Data: []byte("nothing here\n")},
+			"gopkg.in/go.mod":         {Data: []byte("module gopkg.in\n")},
+			"gopkg.in/yaml.v3/README": {Data: []byte("nothing here\n")},
+		}),
+	}
+
+	name, err := r.InspectPackageName("/project/alfa")
+	assert.NoError(t, err)
+	assert.Equal(t, &PackageName{Name: "alfa", Source: NameFromClause}, name)
+
+	name, err = r.InspectPackageName("/project")
+	var noGo *ErrNoGoFiles
+	assert.True(t, errors.As(err, &noGo))
+	assert.Equal(t, &PackageName{Name: "project", Source: NameFromImportPath}, name)
+
+	name, err = r.InspectPackageName("/project/beta")
+	var conflict *ErrConflictingPackages
+	if assert.True(t, errors.As(err, &conflict)) {
+		assert.Equal(t, []string{"alfa", "beta"}, conflict.Names)
+		assert.Equal(t, []string{"a.go", "b.go"}, conflict.Files)
+	}
+	assert.Equal(t, &PackageName{Name: "beta", Source: NameFromImportPath}, name)
+
+	name, _ = r.InspectPackageName("/gopkg.in/yaml.v3")
+	assert.Equal(t, &PackageName{Name: "yaml", Source: NameFromImportPath}, name)
+
+	name, err = r.InspectPackageName("/unknown/dir")
+	var unreadable *ErrUnreadableDir
+	assert.True(t, errors.As(err, &unreadable))
+	assert.Equal(t, &PackageName{Name: "dir", Source: NameFromDir}, name)
+}
+
+func TestInspectPackageName(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "godetector")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(tmp)
+	writeFile(t, filepath.Join(tmp, "go.mod"), "module example.com/project\n")
+	writeFile(t, filepath.Join(tmp, "beta", "a.go"), "package alfa\n")
+
+	name, err := InspectPackageName(filepath.Join(tmp, "beta"))
+	assert.NoError(t, err)
+	assert.Equal(t, &PackageName{Name: "alfa", Source: NameFromClause}, name)
+
+	clause, err := DetectPackageName(filepath.Join(tmp, "beta"))
+	assert.NoError(t, err)
+	assert.Equal(t, "alfa", clause)
+
+	_, err = DetectPackageName(tmp)
+	var noGo *ErrNoGoFiles
+	assert.True(t, errors.As(err, &noGo))
+}
+
+func TestImportPathToAssumedName(t *testing.T) {
+	for importPath, name := range map[string]string{
+		"net/http":                    "http",
+		"github.com/foo/bar/v2":       "bar",
+		"gopkg.in/yaml.v3":            "yaml",
+		"github.com/mattn/go-sqlite3": "sqlite3",
+		"github.com/foo/bar-go":       "bar",
+		"example.com/v2":              "example",
+		"v2":                          "v2",
+	} {
+		assert.Equal(t, name, ImportPathToAssumedName(importPath), importPath)
+	}
+}
@@ -0,0 +1,148 @@
+package godetector
+
+import (
+	"errors"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// List packages by directory or pattern from work directory (see Resolver.ListPackages)
+func ListPackages(pattern string, workDir string) ([]*Import, error) {
+	return DefaultResolver().ListPackages(pattern, workDir)
+}
+
+// List packages by directory or go-command-style pattern from work directory:
+//
+//	./dir/... or /abs/dir/... - all packages in directory tree (relative to work directory or absolute)
+//	./dir or /abs/dir         - single package in directory
+//	example.com/mod/...       - all packages which import path starts with example.com/mod
+//	example.com/mod/pkg       - single package
+//
+// Only patterns starting with ./, ../ or / are directories, other patterns (like dir/...) are import paths.
+// Directory tree is walked without crossing nested module boundaries, and directories testdata, vendor and
+// starting with '_' or '.' are skipped like go command does. Packages are sorted by import path
+func (r *Resolver) ListPackages(pattern string, workDir string) ([]*Import, error) {
+	recursive := pattern == "..." || strings.HasSuffix(pattern, "/...")
+	base := strings.TrimSuffix(strings.TrimSuffix(pattern, "..."), "/")
+	if isFilesystemPath(pattern) || base == "" {
+		if base == "" {
+			base = "."
+		}
+		if !filepath.IsAbs(base) {
+			base = filepath.Join(workDir, filepath.FromSlash(base))
+		}
+		if !recursive {
+			return r.listSinglePackage(base)
+		}
+		return r.listPackagesInTree(base, func(string) bool { return true })
+	}
+	info, err := r.InspectImport(base, workDir)
+	if err != nil {
+		return nil, err
+	}
+	if !recursive {
+		return r.listSinglePackage(info.ImportDir)
+	}
+	return r.listPackagesInTree(info.ImportDir, func(importPath string) bool {
+		return relatesToPackage(base, importPath)
+	})
+}
+
+func (r *Resolver) listSinglePackage(dir string) ([]*Import, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if v, err := r.fs().Stat(dir); err != nil {
+		return nil, err
+	} else if !v.IsDir() {
+		return nil, &ErrNotAPackage{Dir: dir, Reason: "not a directory"}
+	}
+	pkg, err := r.listPackage(dir)
+	if err != nil {
+		return nil, err
+	}
+	if pkg == nil {
+		return nil, &ErrNoGoFiles{Dir: dir}
+	}
+	return []*Import{pkg}, nil
+}
+
+func (r *Resolver) listPackagesInTree(root string, match func(importPath string) bool) ([]*Import, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if v, err := r.fs().Stat(root); err != nil {
+		return nil, err
+	} else if !v.IsDir() {
+		return nil, &ErrNotAPackage{Dir: root, Reason: "not a directory"}
+	}
+	var ans []*Import
+	err = r.walkPackages(root, root, func(pkg *Import) {
+		if match(pkg.Path) {
+			ans = append(ans, pkg)
+		}
+	})
+	sort.Slice(ans, func(i, j int) bool {
+		return ans[i].Path < ans[j].Path
+	})
+	return ans, err
+}
+
+func (r *Resolver) walkPackages(root, dir string, fn func(pkg *Import)) error {
+	if dir != root {
+		// nested module is a separate module
+		if v, err := r.fs().Stat(filepath.Join(dir, "go.mod")); err == nil && !v.IsDir() {
+			return nil
+		}
+	}
+	pkg, err := r.listPackage(dir)
+	if err != nil {
+		return err
+	}
+	if pkg != nil {
+		fn(pkg)
+	}
+	files, err := r.fs().ReadDir(dir)
+	if err != nil {
+		return &ErrUnreadableDir{Dir: dir, Err: err}
+	}
+	for _, file := range files {
+		name := file.Name()
+		if !file.IsDir() || name == "testdata" || name == "vendor" || strings.HasPrefix(name, "_") || strings.HasPrefix(name, ".") {
+			continue
+		}
+		if err := r.walkPackages(root, filepath.Join(dir, name), fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Inspect package in directory. Returns nil if directory has no go files matching build constraints
+func (r *Resolver) listPackage(dir string) (*Import, error) {
+	if _, err := r.DetectPackageName(dir); err != nil {
+		var noGoFiles *ErrNoGoFiles
+		var unreadable *ErrUnreadableDir
+		if errors.As(err, &noGoFiles) || errors.As(err, &unreadable) {
+			return nil, nil
+		}
+	}
+	pkg, err := r.InspectImportByDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files, err := r.fs().ReadDir(dir)
+	if err != nil {
+		return nil, &ErrUnreadableDir{Dir: dir, Err: err}
+	}
+	for _, file := range files {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), "_test.go") {
+			pkg.HasTests = true
+			break
+		}
+	}
+	return pkg, nil
+}
@@ -0,0 +1,86 @@
+package godetector
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"testing/fstest"
+)
+
+func TestResolver_ListPackages(t *testing.T) {
+	r := &Resolver{
+		GOROOT:   "/goroot",
+		ModCache: "/gopath/pkg/mod",
+		Work:     "off",
+		FS: NewFS(fstest.MapFS{
+			"project/go.mod":                                   {Data: []byte("module example.com/project\n\nrequire example.com/dep v1.0.0\n")},
+			"project/main.go":                                  {Data: []byte("package main\n")},
+			"project/alfa/alfa.go":                             {Data: []byte("package alfa\n")},
+			"project/alfa/alfa_test.go":                        {Data: []byte("package alfa\n")},
+			"project/alfa/beta/beta.go":                        {Data: []byte("package beta\n")},
+			"project/alfa/testdata/x.go":                       {Data: []byte("package x\n")},
+			"project/_tools/tool.go":                           {Data: []byte("package tool\n")},
+			"project/.hidden/hidden.go":                        {Data: []byte("package hidden\n")},
+			"project/vendor/example.com/v/v.go":                {Data: []byte("package v\n")},
+			"project/docs/README.md":                           {Data: []byte("docs\n")},
+			"project/nested/go.mod":                            {Data: []byte("module example.com/project/nested\n")},
+			"project/nested/nested.go":                         {Data: []byte("package nested\n")},
+			"gopath/pkg/mod/example.com/dep@v1.0.0/go.mod":     {Data: []byte("module example.com/dep\n")},
+			"gopath/pkg/mod/example.com/dep@v1.0.0/dep.go":     {Data: []byte("package dep\n")},
+			"gopath/pkg/mod/example.com/dep@v1.0.0/sub/sub.go": {Data: []byte("package sub\n")},
+		}),
+	}
+	paths := func(list []*Import) []string {
+		var ans []string
+		for _, pkg := range list {
+			ans = append(ans, pkg.Path)
+		}
+		return ans
+	}
+
+	list, err := r.ListPackages("./...", "/project")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"example.com/project", "example.com/project/alfa", "example.com/project/alfa/beta"}, paths(list))
+		assert.True(t, list[0].IsMain)
+		assert.False(t, list[0].HasTests)
+		assert.True(t, list[1].HasTests)
+		assert.Equal(t, "alfa", list[1].Package)
+	}
+
+	list, err = r.ListPackages("/project/alfa", "/")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"example.com/project/alfa"}, paths(list))
+	}
+
+	list, err = r.ListPackages("./alfa/...", "/project")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"example.com/project/alfa", "example.com/project/alfa/beta"}, paths(list))
+	}
+
+	_, err = r.ListPackages("alfa/...", "/project")
+	assert.True(t, errors.Is(err, &ErrNotFound{}), "pattern without ./ prefix is import path")
+
+	list, err = r.ListPackages(".", "/project")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"example.com/project"}, paths(list))
+	}
+
+	_, err = r.ListPackages("./docs", "/project")
+	var noGoFiles *ErrNoGoFiles
+	assert.True(t, errors.As(err, &noGoFiles))
+
+	list, err = r.ListPackages("example.com/project/alfa/...", "/project")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"example.com/project/alfa", "example.com/project/alfa/beta"}, paths(list))
+	}
+
+	list, err = r.ListPackages("example.com/dep/...", "/project")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"example.com/dep", "example.com/dep/sub"}, paths(list))
+	}
+
+	list, err = r.ListPackages("example.com/dep", "/project")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"example.com/dep"}, paths(list))
+	}
+}
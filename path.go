@@ -2,13 +2,7 @@ package godetector
 
 import (
 	"errors"
-	"fmt"
-	"go/build"
-	"golang.org/x/mod/modfile"
-	"io/ioutil"
-	"os"
 	"path/filepath"
-	"runtime"
 	"strings"
 )
 
@@ -18,14 +12,25 @@ import (
 //
 // It will check all upper directories checking each of them as (a) they have go.mod file (b) directory is under GOROOT/GOPATH
 func FindImportPath(dir string) (string, error) {
-	info, err := InspectDirectory(dir)
+	return DefaultResolver().FindImportPath(dir)
+}
+
+// Find import path of directory using resolver
+func (r *Resolver) FindImportPath(dir string) (string, error) {
+	info, err := r.InspectDirectory(dir)
 	if err != nil {
 		return "", err
 	}
 	return info.Import, nil
 }
 
+// Inspect directory: detect import path, root of package (module, GOPATH, GOROOT) and location type
 func InspectDirectory(dir string) (info *importPathInfo, err error) {
+	return DefaultResolver().InspectDirectory(dir)
+}
+
+// Inspect directory using resolver
+func (r *Resolver) InspectDirectory(dir string) (info *importPathInfo, err error) {
 	const vendor = "vendor/"
 	if strings.HasPrefix(dir, vendor) {
 		return &importPathInfo{
@@ -33,13 +38,21 @@ func InspectDirectory(dir string) (info *importPathInfo, err error) {
 			ImportDir:      dir,
 			LocationType:   InLocalVendor,
 			Import:         dir[len(vendor):],
+			resolver:       r,
 		}, nil
 	}
 	dir, err = filepath.Abs(dir)
 	if err != nil {
 		return nil, err
 	}
-	return inspectDirectory(dir)
+	info, err = r.inspectDirectory(dir)
+	if err != nil {
+		return nil, err
+	}
+	if info.LocationType == GoMod {
+		info.Module = r.markWorkspace(info.Module, dir)
+	}
+	return info, nil
 }
 
 type importPathInfo struct {
@@ -47,62 +60,107 @@ type importPathInfo struct {
 	ImportDir      string
 	LocationType   LocationType
 	Import         string
+	Version        string
+	Replaced       bool
+	Chain          []string
+	Module         *Module
+	resolver       *Resolver // resolver which produced info. Not cached: cache could be shared between resolvers
+}
+
+// Resolver which produced info or default resolver
+func (ipi *importPathInfo) origin() *Resolver {
+	if ipi.resolver != nil {
+		return ipi.resolver
+	}
+	return DefaultResolver()
 }
 
-func inspectDirectory(dir string) (*importPathInfo, error) {
+func (r *Resolver) scanDirectory(dir string) (*importPathInfo, error) {
 	if dir == "" {
-		return nil, errors.New("undetectable dir")
+		return nil, &ErrNotAPackage{Dir: dir, Reason: "empty path"}
 	}
-	if v, err := os.Stat(dir); err != nil {
+	if v, err := r.fs().Stat(dir); err != nil {
 		return nil, err
 	} else if !v.IsDir() {
-		return nil, fmt.Errorf("%s is not a dir", dir)
+		return nil, &ErrNotAPackage{Dir: dir, Reason: "not a directory"}
+	}
+	if r.isGoRoot(dir) {
+		return &importPathInfo{
+			PackageRootDir: dir,
+			ImportDir:      dir,
+			LocationType:   GoRoot,
+			Module:         r.moduleOf(dir),
+		}, nil
 	}
-	if isGoRoot(dir) {
+	if r.isGoRootCmd(dir) {
 		return &importPathInfo{
 			PackageRootDir: dir,
 			ImportDir:      dir,
 			LocationType:   GoRoot,
+			Import:         "cmd",
+			Module:         r.moduleOf(dir),
 		}, nil
 	}
-	if isGoPath(dir) {
+	if r.isGoPath(dir) {
 		return &importPathInfo{
 			PackageRootDir: dir,
 			ImportDir:      dir,
 			LocationType:   GoPath,
 		}, nil
 	}
-	if imp, root, ok := isUnderModCache(dir); ok {
+	if imp, mod, ok, err := r.isUnderModCache(dir); err != nil {
+		return nil, err
+	} else if ok {
 		return &importPathInfo{
-			PackageRootDir: root,
+			PackageRootDir: mod.Dir,
 			ImportDir:      dir,
 			LocationType:   GoCache,
 			Import:         imp,
+			Module:         mod,
 		}, nil
 	}
-	pkg, ok := hasModFile(dir)
-	if ok {
+	var bad *ErrBadGoMod
+	if _, err := r.readModFile(filepath.Join(dir, "go.mod")); errors.As(err, &bad) {
+		return nil, err
+	}
+	if mod := r.moduleOf(dir); mod != nil {
+		mod.Main = true
 		return &importPathInfo{
 			PackageRootDir: dir,
 			ImportDir:      dir,
 			LocationType:   GoMod,
-			Import:         pkg,
+			Import:         mod.Path,
+			Module:         mod,
 		}, nil
 	}
 	mod := filepath.Base(dir)
 	if mod == dir {
-		return nil, errors.New("reached top directory")
+		return nil, &ErrNoModule{Dir: dir}
 	}
-	info, err := inspectDirectory(filepath.Dir(dir))
+	info, err := r.inspectDirectory(filepath.Dir(dir))
 	if err != nil {
+		var noModule *ErrNoModule
+		if errors.As(err, &noModule) {
+			return nil, &ErrNoModule{Dir: dir, Reason: noModule.Reason}
+		}
 		return nil, err
 	}
+	// packages in vendor directory of module are imported without vendor prefix
+	if vendorDir := vendorDirOf(info); vendorDir != "" && isUnder(dir, vendorDir) && dir != vendorDir {
+		return r.scanVendoredDirectory(dir, vendorDir), nil
+	}
 	if info.Import != "" {
+		imp := info.Import + "/" + mod
+		if info.LocationType == GoRoot {
+			// vendored packages of standard library and commands are imported without vendor prefix
+			imp = strings.TrimPrefix(strings.TrimPrefix(imp, "cmd/vendor/"), "vendor/")
+		}
 		return &importPathInfo{
 			ImportDir:      dir,
 			PackageRootDir: info.PackageRootDir,
 			LocationType:   info.LocationType,
-			Import:         info.Import + "/" + mod,
+			Import:         imp,
+			Module:         info.Module,
 		}, nil
 	}
 	return &importPathInfo{
@@ -110,54 +168,64 @@ func inspectDirectory(dir string) (*importPathInfo, error) {
 		PackageRootDir: info.PackageRootDir,
 		LocationType:   info.LocationType,
 		Import:         mod,
+		Module:         info.Module,
 	}, nil
 }
 
-func hasModFile(path string) (string, bool) {
-
-	data, err := ioutil.ReadFile(filepath.Join(path, "go.mod"))
-	if err != nil {
-		return "", false
-	}
-	mod, err := modfile.Parse(path, data, nil)
-	if err != nil {
+func (r *Resolver) hasModFile(path string) (string, bool) {
+	mod, err := r.readModFile(filepath.Join(path, "go.mod"))
+	if err != nil || mod.Module == nil {
 		return "", false
 	}
-
 	return mod.Module.Mod.Path, true
 }
 
-func isUnderModCache(path string) (imp string, root string, ok bool) {
-	GOCACHE := filepath.Join(build.Default.GOPATH, "pkg", "mod")
+// Check that path is under modules cache and detect import path and module. Returns error for directories under
+// cache which don't belong to any module (like download cache)
+func (r *Resolver) isUnderModCache(path string) (imp string, mod *Module, ok bool, err error) {
+	if r.ModCache == "" {
+		return "", nil, false, nil
+	}
 	absPath, _ := filepath.Abs(path)
-	if !relatesToPackage(GOCACHE, absPath) {
-		return "", "", false
-	}
-	t := tail(GOCACHE, absPath)
-	// abc@1.2.3/x/y/z
-	p := strings.Split(t, "@")
-	// p = {abc, 1.2.3/x/y/z}
-	sl := strings.Index(p[1], "/")
-
-	root = filepath.Join(GOCACHE, strings.Split(t, "/")[0])
-
-	if sl > 0 {
-		p[1] = p[1][sl:]
-		return p[0] + p[1], root, true
+	if !isUnder(absPath, r.ModCache) || isRootOf(absPath, r.ModCache) {
+		return "", nil, false, nil
+	}
+	mod, err = r.cacheModule(absPath)
+	if err != nil {
+		return "", nil, true, err
 	}
-	return p[0], root, true
+	rel, _ := filepath.Rel(mod.Dir, absPath)
+	if rel == "." {
+		return mod.Path, mod, true, nil
+	}
+	return mod.Path + "/" + filepath.ToSlash(rel), mod, true, nil
 }
 
-func isGoPath(path string) bool {
-	GOPATH := filepath.Join(build.Default.GOPATH, "src")
-	return isRootOf(path, GOPATH)
+func (r *Resolver) isGoPath(path string) bool {
+	for _, gopath := range r.GOPATH {
+		if isRootOf(path, filepath.Join(gopath, "src")) {
+			return true
+		}
+	}
+	return false
 }
 
-func isGoRoot(path string) bool {
-	GOROOT := filepath.Join(runtime.GOROOT(), "src")
+func (r *Resolver) isGoRoot(path string) bool {
+	if r.GOROOT == "" {
+		return false
+	}
+	GOROOT := filepath.Join(r.GOROOT, "src")
 	return isRootOf(path, GOROOT)
 }
 
+// GOROOT/src/cmd has own go.mod, but it is part of go distribution
+func (r *Resolver) isGoRootCmd(path string) bool {
+	if r.GOROOT == "" {
+		return false
+	}
+	return isRootOf(path, filepath.Join(r.GOROOT, "src", "cmd"))
+}
+
 func isRootOf(path, root string) bool {
 	root, _ = filepath.Abs(root)
 	path, _ = filepath.Abs(path)
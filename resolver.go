@@ -0,0 +1,164 @@
+package godetector
+
+import (
+	"bytes"
+	"go/build"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Resolver of imports and directories in specific go environment. Use NewResolver to get resolver for current
+// environment and change fields to resolve for another toolchain, platform or isolated (fake) environment.
+//
+// Resolver caches inspected directories, imports, go.mod files and package names (see Cache), so fields should not be changed
+// after first use. Resolver is safe for concurrent use.
+type Resolver struct {
+	GOROOT   string            // location of go distribution
+	GOPATH   []string          // GOPATH entries in order of lookup
+	ModCache string            // location of modules cache (GOMODCACHE)
+	Flags    []string          // go command flags (GOFLAGS)
+	Work     string            // GOWORK: empty for auto-detection, 'off' or path to go.work file
+	Context  build.Context     // build context (GOOS, GOARCH, build tags)
+	FS       FileSystem        // filesystem for all lookups and parsing. Nil means OS filesystem
+	Overlay  map[string][]byte // replaced content of files by absolute path (see NewOverlayFS)
+	Cache    *Cache            // cache of results which could be shared between resolvers. Own cache used if not set
+	Strict   bool              // reject imports which violate internal and vendor visibility rules (see CanImport)
+	Fetch    bool              // fetch missing modules from file:// entries of Proxy into ModCache (see WritableFileSystem)
+	Proxy    string            // GOPROXY list. Only file:// entries are used, network proxies and direct are skipped
+	NoProxy  string            // GONOPROXY (or GOPRIVATE) patterns of modules which should not be fetched from proxy
+	Verify   bool              // verify modules from modules cache by go.sum of main modules (see ErrChecksumMismatch)
+
+	ownCache Cache
+}
+
+var (
+	defaultEnv     *Resolver
+	defaultEnvOnce sync.Once
+)
+
+// Resolver for current environment used by package-level functions. Environment is read once on first use, so later
+// changes of environment are not visible to package-level functions: use NewResolver for them. Every call returns new
+// resolver with own cache, so results are not kept between calls of package-level functions
+func DefaultResolver() *Resolver {
+	defaultEnvOnce.Do(func() {
+		defaultEnv = NewResolver()
+	})
+	return &Resolver{
+		GOROOT:   defaultEnv.GOROOT,
+		GOPATH:   defaultEnv.GOPATH,
+		ModCache: defaultEnv.ModCache,
+		Flags:    defaultEnv.Flags,
+		Work:     defaultEnv.Work,
+		Context:  defaultEnv.Context,
+		Proxy:    defaultEnv.Proxy,
+		NoProxy:  defaultEnv.NoProxy,
+	}
+}
+
+// Create resolver for current environment: environment variables, go env config file and runtime
+func NewResolver() *Resolver {
+	ctx := build.Default
+	ctx.GOROOT = goRoot()
+	ctx.GOPATH = strings.Join(goPaths(), string(filepath.ListSeparator))
+	if goos := goEnv("GOOS"); goos != "" {
+		ctx.GOOS = goos
+	}
+	if goarch := goEnv("GOARCH"); goarch != "" {
+		ctx.GOARCH = goarch
+	}
+	return &Resolver{
+		GOROOT:   ctx.GOROOT,
+		GOPATH:   goPaths(),
+		ModCache: goModCache(),
+		Flags:    goFlags(),
+		Work:     goWork(),
+		Context:  ctx,
+		Proxy:    goEnv("GOPROXY"),
+		NoProxy:  goNoProxy(),
+	}
+}
+
+func (r *Resolver) fs() FileSystem {
+	var fsys = r.FS
+	if fsys == nil {
+		fsys = OSFileSystem{}
+	}
+	if len(r.Overlay) > 0 {
+		return NewOverlayFS(fsys, r.Overlay)
+	}
+	return fsys
+}
+
+// Build context which uses resolver filesystem. Platform and release tags of current runtime used if not defined
+func (r *Resolver) buildContext() *build.Context {
+	ctx := r.Context
+	if ctx.GOOS == "" {
+		ctx.GOOS = runtime.GOOS
+	}
+	if ctx.GOARCH == "" {
+		ctx.GOARCH = runtime.GOARCH
+	}
+	if ctx.Compiler == "" {
+		ctx.Compiler = runtime.Compiler
+	}
+	if len(ctx.ReleaseTags) == 0 {
+		ctx.ReleaseTags = build.Default.ReleaseTags
+	}
+	fsys := r.fs()
+	ctx.OpenFile = func(path string) (io.ReadCloser, error) {
+		data, err := fsys.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	}
+	ctx.ReadDir = fsys.ReadDir
+	ctx.IsDir = func(path string) bool {
+		info, err := fsys.Stat(path)
+		return err == nil && info.IsDir()
+	}
+	return &ctx
+}
+
+// Filter of non-test go files in directory which match build constraints of resolver build context
+func (r *Resolver) buildFilter(dir string) func(os.FileInfo) bool {
+	ctx := r.buildContext()
+	return func(info os.FileInfo) bool {
+		if strings.HasSuffix(info.Name(), "_test.go") {
+			return false
+		}
+		ok, err := ctx.MatchFile(dir, info.Name())
+		return err == nil && ok
+	}
+}
+
+// Filesystem used by resolver. All names are OS-specific absolute paths
+type FileSystem interface {
+	Stat(name string) (os.FileInfo, error)
+	ReadDir(name string) ([]os.FileInfo, error)
+	ReadFile(name string) ([]byte, error)
+}
+
+// Filesystem of operation system
+type OSFileSystem struct{}
+
+func (OSFileSystem) Stat(name string) (os.FileInfo, error) { return os.Stat(name) }
+
+func (OSFileSystem) ReadDir(name string) ([]os.FileInfo, error) { return ioutil.ReadDir(name) }
+
+func (OSFileSystem) ReadFile(name string) ([]byte, error) { return ioutil.ReadFile(name) }
+
+func (OSFileSystem) MkdirAll(name string, perm os.FileMode) error { return os.MkdirAll(name, perm) }
+
+func (OSFileSystem) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return ioutil.WriteFile(name, data, perm)
+}
+
+func (OSFileSystem) Rename(oldName, newName string) error { return os.Rename(oldName, newName) }
+
+func (OSFileSystem) RemoveAll(name string) error { return os.RemoveAll(name) }
@@ -0,0 +1,104 @@
+package godetector
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestResolver_fakeEnvironment(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "godetector")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(tmp)
+	writeFile(t, filepath.Join(tmp, "goroot", "src", "fmt", "print.go"), "package fmt\n")
+	writeFile(t, filepath.Join(tmp, "gopath", "src", "example.com", "legacy", "legacy.go"), "package legacy\n")
+	writeFile(t, filepath.Join(tmp, "modcache", "example.com", "dep@v1.0.0", "dep.go"), "package dep\n")
+	writeFile(t, filepath.Join(tmp, "project", "go.mod"), "module example.com/project\n\nrequire example.com/dep v1.0.0\n")
+
+	r := &Resolver{
+		GOROOT:   filepath.Join(tmp, "goroot"),
+		GOPATH:   []string{filepath.Join(tmp, "gopath")},
+		ModCache: filepath.Join(tmp, "modcache"),
+		Work:     "off",
+	}
+	project := filepath.Join(tmp, "project")
+
+	info, err := r.InspectImport("fmt", project)
+	if assert.NoError(t, err) {
+		assert.Equal(t, GoRoot, info.LocationType)
+		assert.Equal(t, filepath.Join(tmp, "goroot", "src", "fmt"), info.ImportDir)
+		assert.Equal(t, "fmt", info.Import)
+	}
+
+	info, err = r.InspectImport("example.com/dep", project)
+	if assert.NoError(t, err) {
+		assert.Equal(t, GoCache, info.LocationType)
+		assert.Equal(t, "example.com/dep", info.Import)
+		assert.Equal(t, "v1.0.0", info.Version)
+	}
+
+	info, err = r.InspectImport("example.com/legacy", project)
+	if assert.NoError(t, err) {
+		assert.Equal(t, GoPath, info.LocationType)
+		assert.Equal(t, "legacy", r.FindPackageNameByDir(info.ImportDir))
+	}
+
+	_, err = r.InspectImport("net/http", project)
+	assert.Error(t, err)
+}
+
+func TestResolver_ToImport(t *testing.T) {
+	r := &Resolver{
+		ModCache: "/mod",
+		Work:     "off",
+		FS: NewFS(fstest.MapFS{
+			"project/go.mod":                    {Data: []byte("module example.com/project\n\nrequire example.com/dep v1.0.0\n")},
+			"mod/example.com/dep@v1.0.0/go.mod": {Data: []byte("module example.com/dep\n")},
+			"mod/example.com/dep@v1.0.0/a/a.go": {Data: []byte("package alfa\n")},
+		}),
+	}
+	info, err := r.InspectImport("example.com/dep/a", "/project")
+	if !assert.NoError(t, err) {
+		return
+	}
+	imp := info.ToImport()
+	assert.Equal(t, "alfa", imp.Package, "package name should be read by the same resolver")
+	root, err := info.Root()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "example.com/dep", root.Path)
+		assert.Equal(t, GoCache, root.Type)
+	}
+}
+
+func TestDefaultResolver_noStaleResults(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "godetector")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(tmp)
+	pkg := filepath.Join(tmp, "pkg")
+	writeFile(t, filepath.Join(tmp, "go.mod"), "module example.com/one\n")
+	writeFile(t, filepath.Join(pkg, "pkg.go"), "package alfa\n")
+
+	assert.Equal(t, "alfa", FindPackageNameByDir(pkg))
+	path, err := FindImportPath(pkg)
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com/one/pkg", path)
+
+	writeFile(t, filepath.Join(tmp, "go.mod"), "module example.com/two\n")
+	writeFile(t, filepath.Join(pkg, "pkg.go"), "package beta\n")
+
+	assert.Equal(t, "beta", FindPackageNameByDir(pkg))
+	path, err = FindImportPath(pkg)
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com/two/pkg", path)
+	info, err := InspectImport("example.com/two/pkg", tmp)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "beta", info.ToImport().Package)
+	}
+}
@@ -0,0 +1,74 @@
+package godetector
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Check that import path is a package of standard library of current environment (see Resolver.IsStandardPackage)
+func IsStandardPackage(importPath string) bool {
+	return DefaultResolver().IsStandardPackage(importPath)
+}
+
+// Check that import path is a package of standard library: it should be in GOROOT package list. Commands (cmd/...)
+// and vendored packages of GOROOT are not part of standard library
+func (r *Resolver) IsStandardPackage(importPath string) bool {
+	list, err := r.StdPackages()
+	if err != nil {
+		return false
+	}
+	idx := sort.SearchStrings(list, importPath)
+	return idx < len(list) && list[idx] == importPath
+}
+
+// Sorted import paths of all standard library packages in GOROOT (like 'go list std' but without vendored packages).
+// The list is built once by walking GOROOT/src and cached. Resolver without GOROOT has no standard packages.
+func (r *Resolver) StdPackages() ([]string, error) {
+	if r.GOROOT == "" {
+		return nil, nil
+	}
+	root := filepath.Join(r.GOROOT, "src")
+	value, err := r.cache().get(r.fs(), cacheKey{kind: cacheStdList, path: root}, func() (interface{}, []string, error) {
+		var list []string
+		err := r.walkStdPackages(root, "", &list)
+		sort.Strings(list)
+		return list, []string{root, filepath.Join(r.GOROOT, "VERSION")}, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]string), nil
+}
+
+func (r *Resolver) walkStdPackages(dir string, importPath string, list *[]string) error {
+	files, err := r.fs().ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var hasGoFiles bool
+	for _, file := range files {
+		name := file.Name()
+		if !file.IsDir() {
+			hasGoFiles = hasGoFiles || (strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go"))
+			continue
+		}
+		if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata" || name == "vendor" {
+			continue
+		}
+		if importPath == "" && name == "cmd" {
+			continue
+		}
+		child := name
+		if importPath != "" {
+			child = importPath + "/" + name
+		}
+		if err := r.walkStdPackages(filepath.Join(dir, name), child, list); err != nil {
+			return err
+		}
+	}
+	if hasGoFiles && importPath != "" {
+		*list = append(*list, importPath)
+	}
+	return nil
+}
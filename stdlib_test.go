@@ -0,0 +1,69 @@
+package godetector
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"testing/fstest"
+)
+
+func testGoRootResolver() *Resolver {
+	return &Resolver{
+		GOROOT: "/goroot",
+		Work:   "off",
+		FS: NewFS(fstest.MapFS{
+			"goroot/src/go.mod":                                        {Data: []byte("module std\n\ngo 1.21\n")},
+			"goroot/src/net/net.go":                                    {Data: []byte("package net\n")},
+			"goroot/src/net/http/http.go":                              {Data: []byte("package http\n")},
+			"goroot/src/net/http/testdata/x.go":                        {Data: []byte("package x\n")},
+			"goroot/src/internal/abi/abi.go":                           {Data: []byte("package abi\n")},
+			"goroot/src/_skip/skip.go":                                 {Data: []byte("package skip\n")},
+			"goroot/src/vendor/modules.txt":                            {Data: []byte("# golang.org/x/net v0.1.0\n## explicit\ngolang.org/x/net/http/httpguts\n")},
+			"goroot/src/vendor/golang.org/x/net/http/httpguts/guts.go": {Data: []byte("package httpguts\n")},
+			"goroot/src/cmd/go.mod":                                    {Data: []byte("module cmd\n\ngo 1.21\n\nrequire golang.org/x/mod v0.12.0\n")},
+			"goroot/src/cmd/go/main.go":                                {Data: []byte("package main\n")},
+			"goroot/src/cmd/vendor/modules.txt":                        {Data: []byte("# golang.org/x/mod v0.12.0\n## explicit\ngolang.org/x/mod/modfile\n")},
+			"goroot/src/cmd/vendor/golang.org/x/mod/modfile/rule.go":   {Data: []byte("package modfile\n")},
+		}),
+	}
+}
+
+func TestResolver_goRootVendor(t *testing.T) {
+	r := testGoRootResolver()
+	info, err := r.InspectImport("golang.org/x/net/http/httpguts", "/goroot/src/net/http")
+	if assert.NoError(t, err) {
+		assert.Equal(t, InLocalVendor, info.LocationType)
+		assert.Equal(t, "/goroot/src/vendor/golang.org/x/net/http/httpguts", info.ImportDir)
+		assert.Equal(t, "v0.1.0", info.Version)
+	}
+
+	info, err = r.InspectImport("golang.org/x/mod/modfile", "/goroot/src/cmd/go")
+	if assert.NoError(t, err) {
+		assert.Equal(t, InLocalVendor, info.LocationType)
+		assert.Equal(t, "/goroot/src/cmd/vendor/golang.org/x/mod/modfile", info.ImportDir)
+	}
+
+	info, err = r.InspectDirectory("/goroot/src/cmd/go")
+	if assert.NoError(t, err) {
+		assert.Equal(t, GoRoot, info.LocationType)
+		assert.Equal(t, "cmd/go", info.Import)
+	}
+
+	info, err = r.InspectDirectory("/goroot/src/vendor/golang.org/x/net/http/httpguts")
+	if assert.NoError(t, err) {
+		assert.Equal(t, GoRoot, info.LocationType)
+		assert.Equal(t, "golang.org/x/net/http/httpguts", info.Import)
+	}
+}
+
+func TestResolver_IsStandardPackage(t *testing.T) {
+	r := testGoRootResolver()
+	list, err := r.StdPackages()
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"internal/abi", "net", "net/http"}, list)
+	}
+	assert.True(t, r.IsStandardPackage("net/http"))
+	assert.True(t, r.IsStandardPackage("internal/abi"))
+	assert.False(t, r.IsStandardPackage("cmd/go"))
+	assert.False(t, r.IsStandardPackage("golang.org/x/net/http/httpguts"))
+	assert.False(t, r.IsStandardPackage("example.com/pkg"))
+}
@@ -0,0 +1,166 @@
+package godetector
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/sumdb/dirhash"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// Checksums from go.sum files by '<module> <version>' and '<module> <version>/go.mod' keys
+type goSums map[string][]string
+
+// Read go.sum files. Missed files are ignored
+func (r *Resolver) readGoSums(files []string) goSums {
+	var sums = make(goSums)
+	for _, file := range files {
+		data, err := r.fs().ReadFile(file)
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) != 3 {
+				continue
+			}
+			key := fields[0] + " " + fields[1]
+			sums[key] = append(sums[key], fields[2])
+		}
+	}
+	return sums
+}
+
+// Check checksum of module files (or go.mod file) by go.sum. Returns *ErrChecksumMismatch if go.sum has no such checksum
+func (sums goSums) check(mod module.Version, goMod bool, hash string) error {
+	key := mod.Path + " " + mod.Version
+	if goMod {
+		key += "/go.mod"
+	}
+	for _, h := range sums[key] {
+		if h == hash {
+			return nil
+		}
+	}
+	return &ErrChecksumMismatch{
+		Module:   mod.Path,
+		Version:  mod.Version,
+		GoMod:    goMod,
+		Expected: strings.Join(sums[key], ", "),
+		Actual:   hash,
+	}
+}
+
+// Checksum of go.mod file in go.sum format (h1:...)
+func hashModFile(data []byte) (string, error) {
+	return dirhash.Hash1([]string{"go.mod"}, func(string) (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	})
+}
+
+// Checksum of module zip archive in go.sum format (h1:...)
+func hashZip(z *zip.Reader) (string, error) {
+	var files = make([]string, 0, len(z.File))
+	var index = make(map[string]*zip.File, len(z.File))
+	for _, file := range z.File {
+		files = append(files, file.Name)
+		index[file.Name] = file
+	}
+	return dirhash.Hash1(files, func(name string) (io.ReadCloser, error) {
+		return index[name].Open()
+	})
+}
+
+// Checksums of module directory and its go.mod with cache. Files are hashed the same way as module zip archive:
+// with <module>@<version>/ prefix. Go.mod is taken from download cache, module directory or synthesized for modules
+// without go.mod like go command does. Result depends on all files of module
+func (r *Resolver) moduleHashes(mod module.Version, dir string) ([2]string, error) {
+	value, err := r.cache().get(r.fs(), cacheKey{kind: cacheModHash, path: dir}, func() (interface{}, []string, error) {
+		var files []string
+		if err := r.listFiles(dir, "", &files); err != nil {
+			return nil, []string{dir}, err
+		}
+		var deps = []string{dir}
+		var names = make([]string, 0, len(files))
+		for _, file := range files {
+			deps = append(deps, filepath.Join(dir, filepath.FromSlash(file)))
+			names = append(names, mod.Path+"@"+mod.Version+"/"+file)
+		}
+		dirHash, err := dirhash.Hash1(names, func(name string) (io.ReadCloser, error) {
+			data, err := r.fs().ReadFile(filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(name, mod.Path+"@"+mod.Version+"/"))))
+			if err != nil {
+				return nil, err
+			}
+			return ioutil.NopCloser(bytes.NewReader(data)), nil
+		})
+		if err != nil {
+			return nil, deps, err
+		}
+		modData, modFile := r.moduleGoMod(mod, dir)
+		if modFile != "" {
+			deps = append(deps, modFile)
+		}
+		modHash, err := hashModFile(modData)
+		return [2]string{dirHash, modHash}, deps, err
+	})
+	if err != nil {
+		return [2]string{}, err
+	}
+	return value.([2]string), nil
+}
+
+// Content of go.mod of module version. Returns content and file location (empty for synthesized go.mod)
+func (r *Resolver) moduleGoMod(mod module.Version, dir string) ([]byte, string) {
+	if file, err := r.downloadCacheFile(mod, ".mod"); err == nil {
+		if data, err := r.fs().ReadFile(file); err == nil {
+			return data, file
+		}
+	}
+	file := filepath.Join(dir, "go.mod")
+	if data, err := r.fs().ReadFile(file); err == nil {
+		return data, file
+	}
+	return []byte("module " + modfile.AutoQuote(mod.Path) + "\n"), ""
+}
+
+// Collect slash-separated names of all files in directory recursively
+func (r *Resolver) listFiles(dir, prefix string, files *[]string) error {
+	list, err := r.fs().ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, item := range list {
+		name := prefix + item.Name()
+		if item.IsDir() {
+			if err := r.listFiles(filepath.Join(dir, item.Name()), name+"/", files); err != nil {
+				return err
+			}
+			continue
+		}
+		*files = append(*files, name)
+	}
+	return nil
+}
+
+// Verify module version in modules cache by go.sum files. Returns *ErrChecksumMismatch if checksums don't match
+func (r *Resolver) verifyModule(mod module.Version, sumFiles []string) error {
+	dir, err := r.moduleCacheDir(mod)
+	if err != nil {
+		return err
+	}
+	hashes, err := r.moduleHashes(mod, dir)
+	if err != nil {
+		return err
+	}
+	sums := r.readGoSums(sumFiles)
+	if err := sums.check(mod, true, hashes[1]); err != nil {
+		return err
+	}
+	return sums.check(mod, false, hashes[0])
+}
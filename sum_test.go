@@ -0,0 +1,48 @@
+package godetector
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"testing/fstest"
+)
+
+func TestResolver_Verify(t *testing.T) {
+	// checksums of real module from modules cache should match go.sum of this repository
+	r := NewResolver()
+	r.Verify = true
+	info, err := r.InspectImport("golang.org/x/mod/modfile", ".")
+	if assert.NoError(t, err) {
+		assert.Equal(t, GoCache, info.LocationType)
+	}
+
+	fsys := fstest.MapFS{
+		"gopath/pkg/mod/example.com/dep@v1.0.0/go.mod": {Data: []byte("module example.com/dep\n")},
+		"gopath/pkg/mod/example.com/dep@v1.0.0/dep.go": {Data: []byte("package dep\n")},
+		"project/go.mod": {Data: []byte("module example.com/project\n\nrequire example.com/dep v1.0.0\n")},
+	}
+	r = &Resolver{ModCache: "/gopath/pkg/mod", Work: "off", Verify: true, FS: NewFS(fsys)}
+	_, err = r.InspectImport("example.com/dep", "/project")
+	var mismatch *ErrChecksumMismatch
+	if assert.True(t, errors.As(err, &mismatch), "missing go.sum entry") {
+		assert.Equal(t, "", mismatch.Expected)
+		assert.True(t, mismatch.GoMod)
+	}
+
+	modHash, _ := hashModFile([]byte("module example.com/dep\n"))
+	fsys["project/go.sum"] = &fstest.MapFile{Data: []byte("example.com/dep v1.0.0 h1:bad=\nexample.com/dep v1.0.0/go.mod " + modHash + "\n")}
+	r = &Resolver{ModCache: "/gopath/pkg/mod", Work: "off", Verify: true, FS: NewFS(fsys)}
+	_, err = r.InspectImport("example.com/dep", "/project")
+	if assert.True(t, errors.As(err, &mismatch)) {
+		assert.Equal(t, "example.com/dep", mismatch.Module)
+		assert.Equal(t, "v1.0.0", mismatch.Version)
+		assert.False(t, mismatch.GoMod)
+		assert.Equal(t, "h1:bad=", mismatch.Expected)
+		assert.NotEmpty(t, mismatch.Actual)
+	}
+
+	fsys["project/go.sum"] = &fstest.MapFile{Data: []byte("example.com/dep v1.0.0 " + mismatch.Actual + "\nexample.com/dep v1.0.0/go.mod " + modHash + "\n")}
+	r = &Resolver{ModCache: "/gopath/pkg/mod", Work: "off", Verify: true, FS: NewFS(fsys)}
+	_, err = r.InspectImport("example.com/dep", "/project")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,99 @@
+package godetector
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Rules of import resolution recorded in trace
+const (
+	RuleMainModule = "main module"
+	RuleWorkspace  = "workspace"
+	RuleGoRoot     = "GOROOT"
+	RuleVendor     = "vendor"
+	RuleRequire    = "require"
+	RuleReplace    = "replace"
+	RuleGoPath     = "GOPATH"
+	RuleVisibility = "visibility"
+)
+
+// Resolution trace of import: every examined candidate location in order of lookup
+type Trace struct {
+	ImportPath string
+	From       string      // work directory
+	Steps      []TraceStep // examined candidates
+	Result     string      // directory of resolved package. Empty if import is not resolved
+	Err        error       // resolution error
+}
+
+// Examined candidate location of import
+type TraceStep struct {
+	Rule     string // applied rule (RuleMainModule, RuleReplace, ...)
+	Location string // examined directory or file
+	Accepted bool   // candidate is accepted
+	Reason   string // why candidate is accepted or rejected
+}
+
+func (t *Trace) add(rule, location string, accepted bool, reason string) {
+	if t == nil {
+		return
+	}
+	t.Steps = append(t.Steps, TraceStep{Rule: rule, Location: location, Accepted: accepted, Reason: reason})
+}
+
+// Add rejected step with error as a reason
+func (t *Trace) reject(rule, location string, err error) {
+	if t == nil {
+		return
+	}
+	t.add(rule, location, false, err.Error())
+}
+
+// Human-readable trace: one line per step, [+] for accepted and [-] for rejected candidates
+func (t *Trace) String() string {
+	var out strings.Builder
+	out.WriteString(t.ImportPath + " from " + t.From + "\n")
+	for _, step := range t.Steps {
+		mark := "[-]"
+		if step.Accepted {
+			mark = "[+]"
+		}
+		out.WriteString("  " + mark + " " + step.Rule + " " + step.Location + ": " + step.Reason + "\n")
+	}
+	if t.Err != nil {
+		out.WriteString("  => error: " + t.Err.Error() + "\n")
+	} else {
+		out.WriteString("  => " + t.Result + "\n")
+	}
+	return out.String()
+}
+
+// Resolve import from work directory and explain resolution (see Resolver.ExplainImport)
+func ExplainImport(importPath string, workDir string) (*Trace, error) {
+	return DefaultResolver().ExplainImport(importPath, workDir)
+}
+
+// Resolve import from work directory the same way as InspectImport does and record every examined candidate location,
+// applied rule and reason of acceptance or rejection. Final result of import is not taken from cache. Resolution
+// error is saved in trace, returned error is only for invalid work directory
+func (r *Resolver) ExplainImport(importPath string, workDir string) (*Trace, error) {
+	abs, err := filepath.Abs(workDir)
+	if err != nil {
+		return nil, err
+	}
+	trace := &Trace{ImportPath: importPath, From: abs}
+	info, err := r.inspectImport(importPath, abs, trace)
+	if err == nil && r.Strict {
+		if err = r.checkVisibility(abs, info); err != nil {
+			trace.reject(RuleVisibility, info.ImportDir, err)
+		} else {
+			trace.add(RuleVisibility, info.ImportDir, true, "package is visible from work directory")
+		}
+	}
+	if err != nil {
+		trace.Err = err
+		return trace, nil
+	}
+	trace.Result = info.ImportDir
+	return trace, nil
+}
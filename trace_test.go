@@ -0,0 +1,61 @@
+package godetector
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"testing/fstest"
+)
+
+func TestResolver_ExplainImport(t *testing.T) {
+	r := &Resolver{
+		GOROOT:   "/goroot",
+		GOPATH:   []string{"/gopath"},
+		ModCache: "/gopath/pkg/mod",
+		Work:     "off",
+		FS: NewFS(fstest.MapFS{
+			"goroot/src/fmt/print.go":                          {Data: []byte("package fmt\n")},
+			"gopath/pkg/mod/example.com/dep@v1.0.0/go.mod":     {Data: []byte("module example.com/dep\n")},
+			"gopath/pkg/mod/example.com/dep@v1.0.0/sub/sub.go": {Data: []byte("package sub\n")},
+			"project/go.mod":                                   {Data: []byte("module example.com/project\n\nrequire (\n\texample.com/dep v1.0.0\n\texample.com/lib v1.0.0\n)\n\nreplace example.com/lib => ../lib\n")},
+			"project/main.go":                                  {Data: []byte("package main\n")},
+			"lib/go.mod":                                       {Data: []byte("module example.com/lib\n")},
+			"lib/lib.go":                                       {Data: []byte("package lib\n")},
+		}),
+	}
+
+	trace, err := r.ExplainImport("example.com/dep/sub", "/project")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, trace.Err)
+	assert.Equal(t, "/gopath/pkg/mod/example.com/dep@v1.0.0/sub", trace.Result)
+	assert.Equal(t, []TraceStep{
+		{Rule: RuleMainModule, Location: "/project", Reason: "import path is outside of main module example.com/project"},
+		{Rule: RuleGoRoot, Location: "/goroot/src/example.com/dep/sub", Reason: trace.Steps[1].Reason},
+		{Rule: RuleRequire, Location: "/gopath/pkg/mod/example.com/dep@v1.0.0/sub", Accepted: true, Reason: "module example.com/dep@v1.0.0 selected by example.com/project -> example.com/dep@v1.0.0"},
+	}, trace.Steps)
+
+	trace, err = r.ExplainImport("example.com/lib", "/project")
+	if assert.NoError(t, err) {
+		last := trace.Steps[len(trace.Steps)-1]
+		assert.Equal(t, RuleReplace, last.Rule)
+		assert.True(t, last.Accepted)
+		assert.Equal(t, "/lib", trace.Result)
+	}
+
+	trace, err = r.ExplainImport("example.com/missing", "/project")
+	if assert.NoError(t, err) {
+		assert.Error(t, trace.Err)
+		assert.Empty(t, trace.Result)
+		last := trace.Steps[len(trace.Steps)-1]
+		assert.Equal(t, RuleGoPath, last.Rule)
+		assert.False(t, last.Accepted)
+		assert.Contains(t, trace.String(), "[-] require /project/go.mod: no required module provides package example.com/missing")
+	}
+
+	trace, err = r.ExplainImport("fmt", "/project")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "/goroot/src/fmt", trace.Result)
+		assert.Contains(t, trace.String(), "[+] GOROOT /goroot/src/fmt: standard library package")
+	}
+}
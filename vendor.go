@@ -0,0 +1,198 @@
+package godetector
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"golang.org/x/mod/semver"
+	"path/filepath"
+	"strings"
+)
+
+// Module description from vendor/modules.txt
+type vendorModule struct {
+	Path     string
+	Version  string
+	Replaced bool     // module defined with => replacement
+	Go       string   // go version from '## explicit; go 1.17' annotation
+	Packages []string // vendored packages of module
+}
+
+// Check that vendor mode is active for module the same way as go command does: -mod flag in GOFLAGS has priority,
+// otherwise vendor mode enabled when vendor directory exists and go.mod go directive is 1.14 or higher
+func (r *Resolver) isVendorMode(modProjectDir string) bool {
+	switch r.goFlagValue("mod") {
+	case "vendor":
+		return true
+	case "mod", "readonly":
+		return false
+	}
+	if v, err := r.fs().Stat(filepath.Join(modProjectDir, "vendor")); err != nil || !v.IsDir() {
+		return false
+	}
+	mod, err := r.readModFile(filepath.Join(modProjectDir, "go.mod"))
+	if err != nil || mod.Go == nil {
+		return false
+	}
+	return semver.Compare("v"+mod.Go.Version, "v1.14") >= 0
+}
+
+// Get value of flag from GOFLAGS variable. Last definition wins
+func (r *Resolver) goFlagValue(name string) string {
+	var value string
+	for _, flag := range r.Flags {
+		flag = strings.TrimLeft(flag, "-")
+		if strings.HasPrefix(flag, name+"=") {
+			value = flag[len(name)+1:]
+		}
+	}
+	return value
+}
+
+// Find package in vendor directory of module using vendor/modules.txt
+func (r *Resolver) findPackageInVendor(importPath, modProjectDir string) (*importPathInfo, error) {
+	vendorDir := filepath.Join(modProjectDir, "vendor")
+	modules, err := r.readVendorModules(filepath.Join(vendorDir, "modules.txt"))
+	if err != nil {
+		return nil, err
+	}
+	found := findVendorModule(modules, importPath)
+	if found == nil {
+		return nil, errors.New("not found in vendor/modules.txt")
+	}
+	info, err := r.inspectDirectory(filepath.Join(vendorDir, filepath.FromSlash(importPath)))
+	if err != nil {
+		return nil, err
+	}
+	return vendoredPackage(vendorDir, info.ImportDir, importPath, found), nil
+}
+
+// Detect import path and vendored module of directory under vendor directory of module by vendor/modules.txt
+func (r *Resolver) scanVendoredDirectory(dir, vendorDir string) *importPathInfo {
+	rel, _ := filepath.Rel(vendorDir, dir)
+	importPath := filepath.ToSlash(rel)
+	modules, _ := r.readVendorModules(filepath.Join(vendorDir, "modules.txt"))
+	if found := findVendorModule(modules, importPath); found != nil {
+		return vendoredPackage(vendorDir, dir, importPath, found)
+	}
+	return &importPathInfo{
+		PackageRootDir: vendorDir,
+		ImportDir:      dir,
+		LocationType:   InLocalVendor,
+		Import:         importPath,
+	}
+}
+
+// Vendor directory of module which could contain directory of the package: vendor directory of main module or
+// vendor directory which contains vendored package. Empty if not applicable
+func vendorDirOf(info *importPathInfo) string {
+	switch {
+	case info.LocationType == GoMod:
+		return filepath.Join(info.PackageRootDir, "vendor")
+	case info.LocationType == InLocalVendor:
+		return upDir(info.ImportDir, strings.Count(info.Import, "/")+1)
+	}
+	return ""
+}
+
+// Find vendored module which provides package
+func findVendorModule(modules []*vendorModule, importPath string) *vendorModule {
+	for _, mod := range modules {
+		for _, pkg := range mod.Packages {
+			if pkg == importPath {
+				return mod
+			}
+		}
+	}
+	// modules.txt may not list packages explicitly (go < 1.11 style), so use longest module path
+	var found *vendorModule
+	for _, mod := range modules {
+		if relatesToPackage(mod.Path, importPath) && (found == nil || len(mod.Path) > len(found.Path)) {
+			found = mod
+		}
+	}
+	return found
+}
+
+func vendoredPackage(vendorDir, dir, importPath string, found *vendorModule) *importPathInfo {
+	return &importPathInfo{
+		PackageRootDir: filepath.Join(vendorDir, filepath.FromSlash(found.Path)),
+		ImportDir:      dir,
+		LocationType:   InLocalVendor,
+		Import:         importPath,
+		Version:        found.Version,
+		Replaced:       found.Replaced,
+		Module: &Module{
+			Path:      found.Path,
+			Version:   found.Version,
+			Dir:       filepath.Join(vendorDir, filepath.FromSlash(found.Path)),
+			Replaced:  found.Replaced,
+			Vendored:  true,
+			GoVersion: found.Go,
+		},
+	}
+}
+
+// Find package in vendor directories of GOPATH project: from work directory up to (excluding) GOPATH/src,
+// the deepest vendor directory wins
+func (r *Resolver) findPackageInGoPathVendor(importPath, dir, srcDir string) (*importPathInfo, error) {
+	for isUnder(dir, srcDir) && dir != srcDir {
+		if info, err := r.inspectDirectory(filepath.Join(dir, "vendor", filepath.FromSlash(importPath))); err == nil {
+			return &importPathInfo{
+				PackageRootDir: filepath.Join(dir, "vendor"),
+				ImportDir:      info.ImportDir,
+				LocationType:   InLocalVendor,
+				Import:         importPath,
+			}, nil
+		}
+		dir = filepath.Dir(dir)
+	}
+	return nil, errors.New("not found in vendor directories")
+}
+
+// Parse vendor/modules.txt. Lines '# path version [=> replacement]' start module, '## ...' are annotations,
+// other lines are packages of the last module
+func (r *Resolver) readVendorModules(file string) ([]*vendorModule, error) {
+	data, err := r.fs().ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var ans []*vendorModule
+	var current *vendorModule
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "##"):
+			if current == nil {
+				continue
+			}
+			for _, annotation := range strings.Split(line[2:], ";") {
+				if fields := strings.Fields(annotation); len(fields) == 2 && fields[0] == "go" {
+					current.Go = fields[1]
+				}
+			}
+		case strings.HasPrefix(line, "#"):
+			fields := strings.Fields(line[1:])
+			current = nil
+			if len(fields) == 0 || fields[0] == "=>" {
+				continue
+			}
+			current = &vendorModule{Path: fields[0]}
+			if len(fields) > 1 && fields[1] != "=>" {
+				current.Version = fields[1]
+			}
+			for _, f := range fields {
+				if f == "=>" {
+					current.Replaced = true
+				}
+			}
+			ans = append(ans, current)
+		case current != nil:
+			current.Packages = append(current.Packages, line)
+		}
+	}
+	return ans, scanner.Err()
+}
@@ -0,0 +1,86 @@
+package godetector
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Check that package in directory can import package by import path (see Resolver.CanImport)
+func CanImport(fromDir, importPath string) error {
+	return DefaultResolver().CanImport(fromDir, importPath)
+}
+
+// Check that package in directory can import package by import path: package should be resolvable and visible by
+// rules of internal directories (any environment) and vendor directories (GOPATH projects, module and GOROOT vendor).
+// Returns *ErrNotVisible if visibility rule is violated
+func (r *Resolver) CanImport(fromDir, importPath string) error {
+	abs, err := filepath.Abs(fromDir)
+	if err != nil {
+		return err
+	}
+	info, err := r.inspectImportCached(importPath, abs)
+	if err != nil {
+		return err
+	}
+	return r.checkVisibility(abs, info)
+}
+
+// Package in internal directory can be imported only by packages rooted at parent of internal directory, vendored
+// package - only by packages rooted at parent of vendor directory. Like go command does, boundaries of module packages
+// are import paths, other boundaries are mapped to directories
+func (r *Resolver) checkVisibility(fromDir string, info *importPathInfo) error {
+	parts := strings.Split(info.Import, "/")
+	rules := []string{"internal"}
+	if info.LocationType == GoPath {
+		// vendor directory of GOPATH project could be referenced by full path
+		rules = append(rules, "vendor")
+	}
+	byImportPath := info.LocationType == GoMod || info.LocationType == GoCache || info.LocationType == GoWork
+	for _, rule := range rules {
+		for i := len(parts) - 1; i >= 0; i-- {
+			if parts[i] != rule {
+				continue
+			}
+			if byImportPath {
+				if parent := strings.Join(parts[:i], "/"); !r.importedFrom(fromDir, parent) {
+					return &ErrNotVisible{ImportPath: info.Import, From: fromDir, Rule: rule, Boundary: parent}
+				}
+			} else if parent := upDir(info.ImportDir, len(parts)-i); !isUnder(fromDir, parent) {
+				return &ErrNotVisible{ImportPath: info.Import, From: fromDir, Rule: rule, Boundary: parent}
+			}
+			break
+		}
+	}
+	if info.LocationType == InLocalVendor || r.isGoRootVendor(info.ImportDir) {
+		// vendored packages are imported by path without vendor prefix, so vendor directory is right above import path
+		if parent := upDir(info.ImportDir, len(parts)+1); !isUnder(fromDir, parent) {
+			return &ErrNotVisible{ImportPath: info.Import, From: fromDir, Rule: "vendor", Boundary: parent}
+		}
+	}
+	return nil
+}
+
+// Check that import path of package in directory starts with import path prefix
+func (r *Resolver) importedFrom(fromDir, prefix string) bool {
+	if prefix == "" {
+		return true
+	}
+	from, err := r.InspectDirectory(fromDir)
+	return err == nil && relatesToPackage(prefix, from.Import)
+}
+
+func (r *Resolver) isGoRootVendor(dir string) bool {
+	if r.GOROOT == "" {
+		return false
+	}
+	src := filepath.Join(r.GOROOT, "src")
+	return isUnder(dir, filepath.Join(src, "vendor")) || isUnder(dir, filepath.Join(src, "cmd", "vendor"))
+}
+
+// Go up by specified number of directories
+func upDir(dir string, levels int) string {
+	for i := 0; i < levels; i++ {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
@@ -0,0 +1,110 @@
+package godetector
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"testing/fstest"
+)
+
+func testVisibilityResolver() *Resolver {
+	return &Resolver{
+		GOROOT:   "/goroot",
+		GOPATH:   []string{"/gopath"},
+		ModCache: "/gopath/pkg/mod",
+		Work:     "off",
+		FS: NewFS(fstest.MapFS{
+			"goroot/src/internal/abi/abi.go":                              {Data: []byte("package abi\n")},
+			"goroot/src/fmt/print.go":                                     {Data: []byte("package fmt\n")},
+			"gopath/pkg/mod/example.com/dep@v1.0.0/go.mod":                {Data: []byte("module example.com/dep\n")},
+			"gopath/pkg/mod/example.com/dep@v1.0.0/internal/x/x.go":       {Data: []byte("package x\n")},
+			"project/go.mod":                                              {Data: []byte("module example.com/project\n\nrequire example.com/dep v1.0.0\n")},
+			"project/internal/alfa/alfa.go":                               {Data: []byte("package alfa\n")},
+			"project/cmd/app/main.go":                                     {Data: []byte("package main\n")},
+			"project/beta/internal/gamma/gamma.go":                        {Data: []byte("package gamma\n")},
+			"gopath/src/example.com/legacy/app/main.go":                   {Data: []byte("package main\n")},
+			"gopath/src/example.com/legacy/app/vendor/example.com/v/v.go": {Data: []byte("package v\n")},
+			"gopath/src/example.com/legacy/lib/lib.go":                    {Data: []byte("package lib\n")},
+		}),
+	}
+}
+
+func TestResolver_CanImport(t *testing.T) {
+	r := testVisibilityResolver()
+	assert.NoError(t, r.CanImport("/project/cmd/app", "example.com/project/internal/alfa"))
+	assert.NoError(t, r.CanImport("/project/beta", "example.com/project/beta/internal/gamma"))
+	assert.NoError(t, r.CanImport("/project/cmd/app", "fmt"))
+	assert.NoError(t, r.CanImport("/gopath/src/example.com/legacy/app", "example.com/v"))
+
+	var notVisible *ErrNotVisible
+	err := r.CanImport("/project/cmd/app", "example.com/project/beta/internal/gamma")
+	if assert.True(t, errors.As(err, &notVisible)) {
+		assert.Equal(t, "internal", notVisible.Rule)
+		assert.Equal(t, "example.com/project/beta", notVisible.Boundary)
+	}
+	err = r.CanImport("/project/cmd/app", "example.com/dep/internal/x")
+	if assert.True(t, errors.As(err, &notVisible)) {
+		assert.Equal(t, "internal", notVisible.Rule)
+		assert.Equal(t, "example.com/dep", notVisible.Boundary)
+	}
+	err = r.CanImport("/project/cmd/app", "internal/abi")
+	if assert.True(t, errors.As(err, &notVisible)) {
+		assert.Equal(t, "/goroot/src", notVisible.Boundary)
+	}
+	// vendor directory of another GOPATH project is not visible
+	err = r.CanImport("/gopath/src/example.com/legacy/lib", "example.com/legacy/app/vendor/example.com/v")
+	if assert.True(t, errors.As(err, &notVisible)) {
+		assert.Equal(t, "vendor", notVisible.Rule)
+		assert.Equal(t, "/gopath/src/example.com/legacy/app", notVisible.Boundary)
+	}
+	info, err := r.InspectImport("example.com/v", "/gopath/src/example.com/legacy/app")
+	if assert.NoError(t, err) {
+		assert.Equal(t, InLocalVendor, info.LocationType)
+		assert.Error(t, r.checkVisibility("/gopath/src/example.com/legacy/lib", info))
+	}
+}
+
+func TestResolver_CanImport_modulePaths(t *testing.T) {
+	r := &Resolver{
+		ModCache: "/mod",
+		Work:     "off",
+		FS: NewFS(fstest.MapFS{
+			"mod/example.com/x@v1.0.0/go.mod":              {Data: []byte("module example.com/x\n")},
+			"mod/example.com/x@v1.0.0/internal/y/y.go":     {Data: []byte("package y\n")},
+			"mod/example.com/internal/tool@v1.0.0/go.mod":  {Data: []byte("module example.com/internal/tool\n")},
+			"mod/example.com/internal/tool@v1.0.0/tool.go": {Data: []byte("package tool\n")},
+			"tools/go.mod":     {Data: []byte("module example.com/x/tools\n\nrequire example.com/x v1.0.0\n")},
+			"tools/gen/gen.go": {Data: []byte("package gen\n")},
+			"app/go.mod":       {Data: []byte("module example.com/app\n\nrequire (\n\texample.com/x v1.0.0\n\texample.com/internal/tool v1.0.0\n)\n")},
+			"app/main.go":      {Data: []byte("package main\n")},
+			"other/go.mod":     {Data: []byte("module other.org/app\n\nrequire example.com/internal/tool v1.0.0\n")},
+			"other/main.go":    {Data: []byte("package main\n")},
+		}),
+	}
+	// nested module imports internal package of parent module by import path
+	assert.NoError(t, r.CanImport("/tools/gen", "example.com/x/internal/y"))
+	// module path contains internal element
+	assert.NoError(t, r.CanImport("/app", "example.com/internal/tool"))
+
+	var notVisible *ErrNotVisible
+	err := r.CanImport("/app", "example.com/x/internal/y")
+	if assert.True(t, errors.As(err, &notVisible)) {
+		assert.Equal(t, "example.com/x", notVisible.Boundary)
+	}
+	err = r.CanImport("/other", "example.com/internal/tool")
+	if assert.True(t, errors.As(err, &notVisible)) {
+		assert.Equal(t, "example.com", notVisible.Boundary)
+	}
+}
+
+func TestResolver_Strict(t *testing.T) {
+	r := testVisibilityResolver()
+	_, err := r.InspectImport("example.com/project/beta/internal/gamma", "/project/cmd/app")
+	assert.NoError(t, err)
+	r.Strict = true
+	_, err = r.InspectImport("example.com/project/beta/internal/gamma", "/project/cmd/app")
+	var notVisible *ErrNotVisible
+	assert.True(t, errors.As(err, &notVisible))
+	_, err = r.InspectImport("example.com/project/internal/alfa", "/project/cmd/app")
+	assert.NoError(t, err)
+}
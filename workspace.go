@@ -0,0 +1,117 @@
+package godetector
+
+import (
+	"golang.org/x/mod/modfile"
+	"path/filepath"
+	"strings"
+)
+
+// Go workspace defined by go.work file
+type workspace struct {
+	File    string             // absolute location of go.work file
+	Modules []*workspaceModule // modules from use directives
+	Replace []*modfile.Replace // workspace-level replacements with absolute filesystem paths
+}
+
+type workspaceModule struct {
+	Path string // module path declared in go.mod
+	Dir  string // absolute module directory
+}
+
+// Find workspace for directory with respect to GOWORK. Returns nil if workspace mode is disabled or go.work not found
+func (r *Resolver) findWorkspace(dir string) (*workspace, error) {
+	file := r.Work
+	if file == "off" {
+		return nil, nil
+	}
+	if file == "" {
+		file = r.findWorkFile(dir)
+		if file == "" {
+			return nil, nil
+		}
+	}
+	return r.parseWorkspace(file)
+}
+
+// Find go.work file in directory or any of upper directories
+func (r *Resolver) findWorkFile(dir string) string {
+	for {
+		file := filepath.Join(dir, "go.work")
+		if v, err := r.fs().Stat(file); err == nil && !v.IsDir() {
+			return file
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func (r *Resolver) parseWorkspace(file string) (*workspace, error) {
+	file, err := filepath.Abs(file)
+	if err != nil {
+		return nil, err
+	}
+	data, err := r.fs().ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	work, err := modfile.ParseWork(file, data, nil)
+	if err != nil {
+		return nil, badGoMod(file, err)
+	}
+	dir := filepath.Dir(file)
+	ws := &workspace{
+		File:    file,
+		Replace: absReplaces(work.Replace, dir),
+	}
+	for _, use := range work.Use {
+		modDir := use.Path
+		if !filepath.IsAbs(modDir) {
+			modDir = filepath.Join(dir, modDir)
+		}
+		pkg, ok := r.hasModFile(modDir)
+		if !ok {
+			continue
+		}
+		ws.Modules = append(ws.Modules, &workspaceModule{
+			Path: pkg,
+			Dir:  modDir,
+		})
+	}
+	return ws, nil
+}
+
+// Find workspace module which provides import path. Longest module path wins
+func (ws *workspace) findModule(importPath string) *workspaceModule {
+	var found *workspaceModule
+	for _, mod := range ws.Modules {
+		if !relatesToPackage(mod.Path, importPath) {
+			continue
+		}
+		if found == nil || len(mod.Path) > len(found.Path) {
+			found = mod
+		}
+	}
+	return found
+}
+
+// Copy replacements converting relative filesystem paths to absolute by base dir
+func absReplaces(replaces []*modfile.Replace, dir string) []*modfile.Replace {
+	var ans = make([]*modfile.Replace, 0, len(replaces))
+	for _, rep := range replaces {
+		if rep.New.Version == "" && !filepath.IsAbs(rep.New.Path) && isFilesystemPath(rep.New.Path) {
+			cp := *rep
+			cp.New.Path = filepath.Join(dir, rep.New.Path)
+			rep = &cp
+		}
+		ans = append(ans, rep)
+	}
+	return ans
+}
+
+// Replacement target without version is filesystem path: ./x, ../x or absolute
+func isFilesystemPath(path string) bool {
+	return filepath.IsAbs(path) || strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../") || path == "." || path == ".."
+}